@@ -0,0 +1,94 @@
+// Package echologger logs Echo requests with the logger package. It lives in its own package so the
+// logger package doesn't depend on Echo.
+package echologger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mritunjaykumar/logger/logger"
+)
+
+const (
+	panicKey = "panic"
+	stackKey = "stack"
+)
+
+// EchoLogger logs every request with the built-in HTTP fields of logger.LogMessage and the correlation id
+// from the X-Request-ID header, generating one when absent. Panics in handlers are recovered, logged at
+// ERROR level with their stack and turned into a 500 response.
+func EchoLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			request := c.Request()
+			correlationID := request.Header.Get(echo.HeaderXRequestID)
+			if correlationID == "" {
+				correlationID = c.Response().Header().Get(echo.HeaderXRequestID)
+			}
+			if correlationID == "" {
+				correlationID = newCorrelationID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, correlationID)
+
+			logMessage := logger.New()
+			start := time.Now()
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					logMessage.AdditionalProperties[panicKey] = fmt.Sprint(recovered)
+					logMessage.AdditionalProperties[stackKey] = string(debug.Stack())
+					err = echo.NewHTTPError(http.StatusInternalServerError)
+				}
+
+				end := time.Now()
+				logMessage.CorrelationId = correlationID
+				logMessage.Method = request.Method
+				logMessage.Path = request.URL.Path
+				logMessage.Query = request.URL.RawQuery
+				logMessage.Protocol = request.Proto
+				logMessage.ClientIP = c.RealIP()
+				logMessage.UserAgent = request.UserAgent()
+				logMessage.Status = responseStatus(c, err)
+				logMessage.StartTime = start.UTC()
+				logMessage.EndTime = end.UTC()
+				logMessage.LatencyNanoSeconds = end.Sub(start).Nanoseconds()
+				if err != nil {
+					logMessage.AdditionalProperties["error"] = err.Error()
+				}
+
+				if _, panicked := logMessage.AdditionalProperties[panicKey]; panicked {
+					logger.ErrorMessage(logMessage)
+				} else {
+					logger.InfoMessage(logMessage)
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// responseStatus provides the status that will be sent, including the one of a returned error
+// which Echo's error handler only writes after the middleware returns.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	if httpError, ok := err.(*echo.HTTPError); ok {
+		return httpError.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// newCorrelationID generates a random 128-bit correlation id.
+func newCorrelationID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,77 @@
+package echologger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mritunjaykumar/logger/logger/loggertest"
+)
+
+func TestEchoLogger(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       echo.HandlerFunc
+		correlationID string
+		status        int64
+		level         string
+	}{
+		{
+			name:          "ok",
+			handler:       func(c echo.Context) error { return c.NoContent(http.StatusNoContent) },
+			correlationID: "abc-123",
+			status:        http.StatusNoContent,
+			level:         "info",
+		},
+		{
+			name:    "error",
+			handler: func(c echo.Context) error { return echo.NewHTTPError(http.StatusNotFound) },
+			status:  http.StatusNotFound,
+			level:   "info",
+		},
+		{
+			name:    "panic",
+			handler: func(c echo.Context) error { panic("boom") },
+			status:  http.StatusInternalServerError,
+			level:   "error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs := loggertest.NewTestLogger()
+			defer logs.Close()
+
+			request := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+			if test.correlationID != "" {
+				request.Header.Set(echo.HeaderXRequestID, test.correlationID)
+			}
+			recorder := httptest.NewRecorder()
+			c := echo.New().NewContext(request, recorder)
+
+			err := EchoLogger()(test.handler)(c)
+			if (err != nil) != (test.status >= http.StatusBadRequest) {
+				t.Errorf("got error %v", err)
+			}
+
+			entries := logs.Entries()
+			if len(entries) != 1 {
+				t.Fatalf("got %v", entries)
+			}
+			entry := entries[0]
+			if entry["level"] != test.level || entry["status"] != test.status || entry["path"] != "/orders/42" {
+				t.Errorf("got %v", entry)
+			}
+			correlationID, _ := entry["correlation-id"].(string)
+			if correlationID == "" || (test.correlationID != "" && correlationID != test.correlationID) {
+				t.Errorf("logged correlation id %q, want %q", correlationID, test.correlationID)
+			}
+			if header := recorder.Header().Get(echo.HeaderXRequestID); header != correlationID {
+				t.Errorf("responded with correlation id %q, want %q", header, correlationID)
+			}
+			if _, found := entry[stackKey]; found != (test.level == "error") {
+				t.Errorf("got %v", entry)
+			}
+		})
+	}
+}
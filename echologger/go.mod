@@ -0,0 +1,10 @@
+module github.com/mritunjaykumar/logger/echologger
+
+go 1.14
+
+require (
+	github.com/labstack/echo/v4 v4.2.2
+	github.com/mritunjaykumar/logger v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/mritunjaykumar/logger => ../
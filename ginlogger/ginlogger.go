@@ -0,0 +1,55 @@
+// Package ginlogger logs Gin requests with the logger package. It lives in its own package so the
+// logger package doesn't depend on Gin.
+package ginlogger
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mritunjaykumar/logger/logger"
+)
+
+const (
+	contextKey = "logger" // Gin context key of the request-scoped logger
+	errorsKey  = "errors"
+)
+
+// GinLogger logs every request with the built-in HTTP fields of logger.LogMessage and the errors
+// collected in c.Errors. Handlers get a request-scoped logger through FromContext.
+func GinLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Set(contextKey, logger.NewLogger(logger.Fields{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		}))
+
+		c.Next()
+
+		end := time.Now()
+		logMessage := logger.New()
+		logMessage.Method = c.Request.Method
+		logMessage.Path = c.Request.URL.Path
+		logMessage.Query = c.Request.URL.RawQuery
+		logMessage.Protocol = c.Request.Proto
+		logMessage.ClientIP = c.ClientIP()
+		logMessage.UserAgent = c.Request.UserAgent()
+		logMessage.Status = c.Writer.Status()
+		logMessage.StartTime = start.UTC()
+		logMessage.EndTime = end.UTC()
+		logMessage.LatencyNanoSeconds = end.Sub(start).Nanoseconds()
+		if len(c.Errors) > 0 {
+			logMessage.AdditionalProperties[errorsKey] = c.Errors.Errors()
+		}
+		logger.InfoMessage(logMessage)
+	}
+}
+
+// FromContext returns the request-scoped logger stashed by GinLogger,
+// or a logger without request fields when the middleware isn't installed.
+func FromContext(c *gin.Context) logger.Logger {
+	if requestLogger, ok := c.Value(contextKey).(logger.Logger); ok {
+		return requestLogger
+	}
+	return logger.NewLogger(logger.Fields{})
+}
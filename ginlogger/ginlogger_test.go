@@ -0,0 +1,45 @@
+package ginlogger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mritunjaykumar/logger/logger/loggertest"
+)
+
+func TestGinLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logs := loggertest.NewTestLogger()
+	defer logs.Close()
+
+	router := gin.New()
+	router.Use(GinLogger())
+	router.GET("/orders/:id", func(c *gin.Context) {
+		FromContext(c).Info("getting order")
+		c.Error(http.ErrNoCookie)
+		c.Status(http.StatusNotFound)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/orders/42?expand=items", nil))
+
+	entries := logs.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	if handlerEntry := entries[0]; handlerEntry["method"] != http.MethodGet || handlerEntry["path"] != "/orders/42" {
+		t.Errorf("handler logged %v", handlerEntry)
+	}
+	requestEntry := entries[1]
+	if requestEntry["method"] != http.MethodGet || requestEntry["path"] != "/orders/42" || requestEntry["query"] != "expand=items" {
+		t.Errorf("got %v", requestEntry)
+	}
+	if requestEntry["status"] != int64(http.StatusNotFound) {
+		t.Errorf("logged status %v, want %v", requestEntry["status"], http.StatusNotFound)
+	}
+	if errors, _ := requestEntry[errorsKey].([]interface{}); len(errors) != 1 || errors[0] != http.ErrNoCookie.Error() {
+		t.Errorf("logged errors %v", requestEntry[errorsKey])
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/mritunjaykumar/logger/ginlogger
+
+go 1.14
+
+require (
+	github.com/gin-gonic/gin v1.7.7
+	github.com/mritunjaykumar/logger v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/mritunjaykumar/logger => ../
@@ -2,4 +2,8 @@ module github.com/mritunjaykumar/logger
 
 go 1.14
 
-require go.uber.org/zap v1.15.0
+require (
+	github.com/stretchr/testify v1.5.1 // indirect
+	go.uber.org/zap v1.15.0
+	gopkg.in/yaml.v2 v2.2.8 // indirect
+)
@@ -0,0 +1,10 @@
+module github.com/mritunjaykumar/logger/grpclogger
+
+go 1.14
+
+require (
+	github.com/mritunjaykumar/logger v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.40.0
+)
+
+replace github.com/mritunjaykumar/logger => ../
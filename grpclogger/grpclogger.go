@@ -0,0 +1,115 @@
+// Package grpclogger logs gRPC calls with the logger package and propagates correlation ids
+// through gRPC metadata. It lives in its own package so the logger package doesn't depend on gRPC.
+package grpclogger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"github.com/mritunjaykumar/logger/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultCorrelationIDKey is the metadata key used when Options.CorrelationIDKey is empty.
+	DefaultCorrelationIDKey = "x-request-id"
+	protocol                = "grpc"
+)
+
+type correlationIDContextKey struct{}
+
+// Options configures UnaryServerInterceptor.
+type Options struct {
+	// CorrelationIDKey is the metadata key carrying the correlation id, "x-request-id" by default.
+	CorrelationIDKey string
+}
+
+// UnaryServerInterceptor logs every unary call. The correlation id is read from the incoming metadata,
+// or generated when absent, logged as the correlation-id field and added to the outgoing metadata so
+// calls made by the handler carry it along.
+func UnaryServerInterceptor(options Options) grpc.UnaryServerInterceptor {
+	key := options.CorrelationIDKey
+	if key == "" {
+		key = DefaultCorrelationIDKey
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		correlationID := incomingCorrelationID(ctx, key)
+		if correlationID == "" {
+			correlationID = newCorrelationID()
+		}
+		ctx = context.WithValue(ctx, correlationIDContextKey{}, correlationID)
+		ctx = metadata.AppendToOutgoingContext(ctx, key, correlationID)
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		end := time.Now()
+
+		logMessage := logger.New()
+		logMessage.CorrelationId = correlationID
+		logMessage.Protocol = protocol
+		logMessage.Path = info.FullMethod
+		logMessage.Status = int(status.Code(err))
+		logMessage.ClientIP = peerIP(ctx)
+		logMessage.StartTime = start.UTC()
+		logMessage.EndTime = end.UTC()
+		logMessage.LatencyNanoSeconds = end.Sub(start).Nanoseconds()
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+				logMessage.UserAgent = userAgent[0]
+			}
+		}
+		if err != nil {
+			logMessage.AdditionalProperties["error"] = err.Error()
+		}
+		logger.InfoMessage(logMessage)
+
+		return resp, err
+	}
+}
+
+// CorrelationIDFromContext returns the correlation id set by UnaryServerInterceptor.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDContextKey{}).(string)
+	return correlationID
+}
+
+// incomingCorrelationID reads the correlation id from the incoming metadata.
+func incomingCorrelationID(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// newCorrelationID generates a random 128-bit correlation id.
+func newCorrelationID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+// peerIP returns the IP address of the calling peer.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,61 @@
+package grpclogger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mritunjaykumar/logger/logger/loggertest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptorCorrelationID(t *testing.T) {
+	interceptor := UnaryServerInterceptor(Options{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			name:     "present",
+			ctx:      metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultCorrelationIDKey, "abc-123")),
+			expected: "abc-123",
+		},
+		{
+			name: "absent",
+			ctx:  context.Background(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs := loggertest.NewTestLogger()
+			defer logs.Close()
+
+			var outgoing string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				if values := md.Get(DefaultCorrelationIDKey); len(values) > 0 {
+					outgoing = values[0]
+				}
+				return nil, nil
+			}
+			if _, err := interceptor(test.ctx, nil, info, handler); err != nil {
+				t.Fatal(err)
+			}
+
+			entries := logs.Entries()
+			if len(entries) != 1 {
+				t.Fatalf("got %v", entries)
+			}
+			correlationID, _ := entries[0]["correlation-id"].(string)
+			if correlationID == "" || (test.expected != "" && correlationID != test.expected) {
+				t.Errorf("logged correlation id %q, want %q", correlationID, test.expected)
+			}
+			if outgoing != correlationID {
+				t.Errorf("propagated correlation id %q, want %q", outgoing, correlationID)
+			}
+		})
+	}
+}
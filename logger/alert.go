@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorRateAlert calls Callback when more than Threshold ERROR and higher logs are emitted within Window.
+// After an alert, no other alert is raised for Cooldown. Callback runs in its own goroutine, so it may log.
+type ErrorRateAlert struct {
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+	Callback  func(errorsInWindow int)
+}
+
+// errorRateBuckets is the number of intervals the alert window is split in to count the errors.
+const errorRateBuckets = 60
+
+// errorRateTracker counts the recent errors per interval of Window/errorRateBuckets, so the errors in the
+// window are counted in constant time and memory, up to an interval of precision.
+type errorRateTracker struct {
+	sync.Mutex
+	alert     ErrorRateAlert
+	interval  int64                   // nanoseconds counted in a bucket
+	counts    [errorRateBuckets]int   // errors per bucket
+	intervals [errorRateBuckets]int64 // interval counted in each bucket, since the Unix epoch
+	lastAlert time.Time
+}
+
+func newErrorRateTracker(alert ErrorRateAlert) *errorRateTracker {
+	interval := int64(alert.Window) / errorRateBuckets
+	if interval < 1 {
+		interval = 1
+	}
+	return &errorRateTracker{alert: alert, interval: interval}
+}
+
+// hook is registered as a zap hook and records every ERROR and higher entry.
+func (t *errorRateTracker) hook(entry zapcore.Entry) error {
+	if entry.Level < zapcore.ErrorLevel || t.alert.Callback == nil {
+		return nil
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	now := time.Now()
+	current := now.UnixNano() / t.interval
+	bucket := current % errorRateBuckets
+	if t.intervals[bucket] != current {
+		t.intervals[bucket] = current
+		t.counts[bucket] = 0
+	}
+	t.counts[bucket]++
+
+	errorsInWindow := 0
+	for i, interval := range t.intervals {
+		if interval > current-errorRateBuckets {
+			errorsInWindow += t.counts[i]
+		}
+	}
+
+	inCooldown := !t.lastAlert.IsZero() && now.Sub(t.lastAlert) < t.alert.Cooldown
+	if errorsInWindow > t.alert.Threshold && !inCooldown {
+		t.lastAlert = now
+		go t.alert.Callback(errorsInWindow)
+	}
+	return nil
+}
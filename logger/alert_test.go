@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorRateAlertCooldown(t *testing.T) {
+	alerts := make(chan int, 10)
+	initTestLogger(t, Config{ErrorRateAlert: &ErrorRateAlert{
+		Threshold: 2,
+		Window:    time.Minute,
+		Cooldown:  time.Minute,
+		Callback:  func(errorsInWindow int) { alerts <- errorsInWindow },
+	}})
+
+	for i := 0; i < 10; i++ {
+		Error("failed")
+	}
+
+	select {
+	case errorsInWindow := <-alerts:
+		if errorsInWindow != 3 {
+			t.Errorf("alerted with %v errors in the window", errorsInWindow)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert")
+	}
+	select {
+	case errorsInWindow := <-alerts:
+		t.Errorf("alerted again with %v errors during the cooldown", errorsInWindow)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestErrorRateAlertWindow(t *testing.T) {
+	alerts := make(chan int, 10)
+	initTestLogger(t, Config{ErrorRateAlert: &ErrorRateAlert{
+		Threshold: 2,
+		Window:    300 * time.Millisecond,
+		Callback:  func(errorsInWindow int) { alerts <- errorsInWindow },
+	}})
+
+	Error("expired")
+	Error("expired")
+	time.Sleep(400 * time.Millisecond)
+	Error("failed")
+	Error("failed")
+
+	select {
+	case errorsInWindow := <-alerts:
+		t.Fatalf("alerted with %v errors, counting the ones out of the window", errorsInWindow)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	Error("failed")
+	select {
+	case errorsInWindow := <-alerts:
+		if errorsInWindow != 3 {
+			t.Errorf("alerted with %v errors in the window", errorsInWindow)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert")
+	}
+}
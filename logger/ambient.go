@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ambientMutex   sync.RWMutex
+	ambientEntries = make(map[uint64][]*entry) // entries pushed by Use per goroutine id, innermost last
+)
+
+// Use makes the fields of e ambient while fn runs: every log of the calling goroutine, including the
+// package level functions such as Info, carries them unless it sets the same field itself.
+// Other goroutines, including the ones started by fn, don't get them.
+func Use(e *entry, fn func()) {
+	id := goroutineID()
+	ambientMutex.Lock()
+	ambientEntries[id] = append(ambientEntries[id], e)
+	ambientMutex.Unlock()
+
+	defer func() {
+		ambientMutex.Lock()
+		if entries := ambientEntries[id]; len(entries) > 1 {
+			ambientEntries[id] = entries[:len(entries)-1]
+		} else {
+			delete(ambientEntries, id)
+		}
+		ambientMutex.Unlock()
+	}()
+
+	fn()
+}
+
+// withAmbientFields returns a copy of the log message with the ambient fields of the calling goroutine
+// added, or the message itself when there are none.
+func withAmbientFields(logMessage *LogMessage) *LogMessage {
+	ambientMutex.RLock()
+	scopes := len(ambientEntries)
+	ambientMutex.RUnlock()
+	// Looking up the goroutine id is only worth it while some goroutine is in a Use scope.
+	if scopes == 0 {
+		return logMessage
+	}
+
+	id := goroutineID()
+	ambientMutex.RLock()
+	defer ambientMutex.RUnlock()
+
+	entries := ambientEntries[id]
+	if len(entries) == 0 {
+		return logMessage
+	}
+
+	merged := *logMessage
+	merged.AdditionalProperties = make(map[string]interface{})
+	for _, ambient := range entries {
+		for k, v := range ambient.value {
+			merged.AdditionalProperties[k] = v
+		}
+	}
+	for k, v := range logMessage.AdditionalProperties {
+		merged.AdditionalProperties[k] = v
+	}
+	return &merged
+}
+
+// goroutineID returns the id of the calling goroutine, read from the header of its stack trace,
+// e.g. "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	if i := strings.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(header, 10, 64)
+	return id
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	Use(WithFields(Fields{"request_id": "r-1", "user": "alice"}), func() {
+		Use(WithField("user", "bob"), func() {
+			Info("handled")
+			WithField("step", 2).Info("handled")
+		})
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Info("other goroutine")
+		}()
+		wg.Wait()
+	})
+	Info("outside")
+
+	entries := output.entries(t)
+	if len(entries) != 4 {
+		t.Fatalf("got %v", entries)
+	}
+	if entry := entries[0]; entry["request_id"] != "r-1" || entry["user"] != "bob" {
+		t.Errorf("got %v", entry)
+	}
+	if entry := entries[1]; entry["request_id"] != "r-1" || entry["user"] != "bob" || entry["step"] != float64(2) {
+		t.Errorf("got %v", entry)
+	}
+	for _, entry := range entries[2:] {
+		if _, found := entry["request_id"]; found {
+			t.Errorf("got %v", entry)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package logger
+
+import "sync"
+
+const steps = "steps"
+
+// StepBatch accumulates the steps of a multi-step operation, to log them in a single entry.
+type StepBatch struct {
+	mutex sync.Mutex
+	steps []string
+}
+
+// Batch creates an empty batch of steps:
+//
+//	batch := logger.Batch()
+//	batch.Add("fetched user")
+//	batch.Add("updated quota")
+//	batch.Flush("quota refreshed")
+func Batch() *StepBatch {
+	return &StepBatch{}
+}
+
+// Add records a step.
+func (b *StepBatch) Add(step string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.steps = append(b.steps, step)
+}
+
+// Flush logs msg at INFO level with the steps recorded since the last flush in a steps array.
+func (b *StepBatch) Flush(msg string) {
+	b.mutex.Lock()
+	recorded := b.steps
+	b.steps = nil
+	b.mutex.Unlock()
+
+	logMessage := New()
+	logMessage.Message = msg
+	if len(recorded) > 0 {
+		logMessage.AdditionalProperties[steps] = recorded
+	}
+	infoMessage(logMessage)
+}
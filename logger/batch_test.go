@@ -0,0 +1,24 @@
+package logger
+
+import "testing"
+
+func TestBatch(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	batch := Batch()
+	batch.Add("fetched user")
+	batch.Add("updated quota")
+	batch.Flush("quota refreshed")
+	batch.Flush("nothing new")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	if recorded, _ := entries[0][steps].([]interface{}); len(recorded) != 2 || recorded[0] != "fetched user" || recorded[1] != "updated quota" {
+		t.Errorf("got %v", entries[0])
+	}
+	if _, found := entries[1][steps]; found {
+		t.Errorf("got %v", entries[1])
+	}
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	capturesMutex  sync.RWMutex
+	activeCaptures = make(map[*Capture]bool) // captures started and not stopped yet
+)
+
+// Capture collects the logs written between StartCapture and Stop, e.g. to dump them for diagnostics.
+type Capture struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+// StartCapture starts collecting every log, encoded as in the main output, without changing the outputs.
+func StartCapture() *Capture {
+	capture := &Capture{}
+	capturesMutex.Lock()
+	activeCaptures[capture] = true
+	capturesMutex.Unlock()
+	return capture
+}
+
+// Stop stops collecting logs and returns the ones collected, one line each.
+func (c *Capture) Stop() []string {
+	capturesMutex.Lock()
+	delete(activeCaptures, c)
+	capturesMutex.Unlock()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lines
+}
+
+// captureCore writes the logs to the active captures. It is part of every logger, and only hands
+// entries to the wrapped core, which encodes them, while a capture is active.
+type captureCore struct {
+	zapcore.Core
+}
+
+// newCaptureCore creates the capture core of a logger, wrap decorating its output core like the others.
+func newCaptureCore(encoder zapcore.Encoder, level zapcore.LevelEnabler, wrap func(zapcore.Core) zapcore.Core) *captureCore {
+	return &captureCore{Core: wrap(zapcore.NewCore(encoder, zapcore.AddSync(captureWriter{}), level))}
+}
+
+func (c *captureCore) With(fields []zapcore.Field) zapcore.Core {
+	return &captureCore{Core: c.Core.With(fields)}
+}
+
+func (c *captureCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	capturesMutex.RLock()
+	capturing := len(activeCaptures) > 0
+	capturesMutex.RUnlock()
+
+	if !capturing {
+		return checkedEntry
+	}
+	return c.Core.Check(entry, checkedEntry)
+}
+
+// captureWriter appends every encoded log to the active captures.
+type captureWriter struct{}
+
+func (captureWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+
+	capturesMutex.RLock()
+	defer capturesMutex.RUnlock()
+	for capture := range activeCaptures {
+		capture.mutex.Lock()
+		capture.lines = append(capture.lines, line)
+		capture.mutex.Unlock()
+	}
+	return len(p), nil
+}
@@ -0,0 +1,24 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	Info("before")
+	capture := StartCapture()
+	Info("during")
+	Debug("filtered")
+	lines := capture.Stop()
+	Info("after")
+
+	if len(lines) != 1 || !strings.Contains(lines[0], `"msg":"during"`) {
+		t.Errorf("captured %q", lines)
+	}
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "before,during,after" {
+		t.Errorf("got %v", messages)
+	}
+}
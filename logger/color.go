@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	fullLineColorEncoding = "full-line-color-console"
+	colorReset            = "\x1b[0m"
+)
+
+var (
+	colorBufferPool = buffer.NewPool()
+
+	// levelColors are the ANSI colors zap uses for the level token, applied to the whole line.
+	levelColors = map[zapcore.Level]string{
+		zapcore.DebugLevel:  "\x1b[35m", // magenta
+		zapcore.InfoLevel:   "\x1b[34m", // blue
+		zapcore.WarnLevel:   "\x1b[33m", // yellow
+		zapcore.ErrorLevel:  "\x1b[31m", // red
+		zapcore.DPanicLevel: "\x1b[31m",
+		zapcore.PanicLevel:  "\x1b[31m",
+		zapcore.FatalLevel:  "\x1b[31m",
+	}
+)
+
+// fullLineColorEncoder wraps every line of the console encoder in the color of its level.
+type fullLineColorEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *fullLineColorEncoder) Clone() zapcore.Encoder {
+	return &fullLineColorEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *fullLineColorEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer line.Free()
+
+	color, ok := levelColors[entry.Level]
+	if !ok {
+		color = levelColors[zapcore.ErrorLevel]
+	}
+	content := line.Bytes()
+	newline := len(content) > 0 && content[len(content)-1] == '\n'
+	if newline {
+		content = content[:len(content)-1]
+	}
+
+	colored := colorBufferPool.Get()
+	colored.AppendString(color)
+	colored.Write(content)
+	colored.AppendString(colorReset)
+	if newline {
+		colored.AppendByte('\n')
+	}
+	return colored, nil
+}
+
+// useFullLineColor switches a development configuration to full line colors when every output
+// is a terminal, so piped or file output stays free of escape codes.
+func useFullLineColor(zapConfig *zap.Config, levelNames map[zapcore.Level]string) {
+	if zapConfig.Encoding != "console" {
+		return
+	}
+	for _, outputPath := range zapConfig.OutputPaths {
+		if !isTerminalOutput(outputPath) {
+			return
+		}
+	}
+	zapConfig.Encoding = fullLineColorEncoding
+	zapConfig.EncoderConfig.EncodeLevel = withLevelNames(zapcore.CapitalLevelEncoder, levelNames)
+}
+
+// isTerminalOutput reports whether the output path is stdout or stderr attached to a terminal.
+func isTerminalOutput(outputPath string) bool {
+	var file *os.File
+	switch outputPath {
+	case "stdout":
+		file = os.Stdout
+	case "stderr":
+		file = os.Stderr
+	default:
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFullLineColorEncoder(t *testing.T) {
+	encoder := &fullLineColorEncoder{Encoder: zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())}
+
+	line, err := encoder.EncodeEntry(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "failed"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := line.String(); !strings.HasPrefix(output, levelColors[zapcore.ErrorLevel]) || !strings.HasSuffix(output, colorReset+"\n") {
+		t.Errorf("got %q", output)
+	}
+}
+
+func TestFullLineColorNotATerminal(t *testing.T) {
+	zapConfig := zap.NewDevelopmentConfig()
+	zapConfig.OutputPaths = []string{"buffer://"}
+
+	useFullLineColor(&zapConfig, nil)
+
+	if zapConfig.Encoding != "console" {
+		t.Errorf("got %v encoding for a non-terminal output", zapConfig.Encoding)
+	}
+}
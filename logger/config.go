@@ -0,0 +1,237 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Sink is an additional log destination with its own minimum level.
+// Path accepts anything zap.Open understands: "stdout", "stderr", a file path or a registered sink URL.
+type Sink struct {
+	Path  string
+	Level string
+}
+
+// Config holds the settings used to build the zap logger.
+// Environment variables documented on GetZapLogger are still honored.
+type Config struct {
+	// Sinks are written to in addition to the default outputs. Each sink receives
+	// entries at or above its own Level, independent of LOG_LEVEL.
+	Sinks []Sink
+
+	// DevJSONFields renders the fields of development logs as a trailing compact JSON
+	// object ("message {...}") instead of key=value pairs.
+	DevJSONFields bool
+
+	// IncludeSourceSnippet attaches the caller's line of source code as a "source" field
+	// to ERROR and higher logs. It only applies in development, since it reads the file from disk.
+	IncludeSourceSnippet bool
+
+	// Strict makes Init fail on unrecognized LOG_LEVEL, LOGGER_ENVIRONMENT or Encoding values
+	// instead of silently falling back to the defaults.
+	Strict bool
+
+	// Sampling limits repeated logs, nil disables sampling.
+	Sampling *SamplingConfig
+
+	// LatencyUnit is the unit of the built-in latency field: "ns" (default), "us", "ms" or "s".
+	LatencyUnit string
+
+	// DevFloatFormat is the fmt format of floating point fields in development logs, e.g. "%.4g".
+	// Go's default formatting is used when empty.
+	DevFloatFormat string
+
+	// FullLineColor colors the whole development log line by level, e.g. errors in red, instead of
+	// the level only. It's ignored unless every output is a terminal.
+	FullLineColor bool
+
+	// FlushLevel syncs the outputs right after every log at or above this level, and no longer after
+	// every log, so buffered outputs keep important logs on a crash without syncing on every line.
+	// Every log is synced when empty.
+	FlushLevel string
+
+	// BytesEncoding is how WithBytes renders binary data: BytesHex (default) or BytesBase64.
+	BytesEncoding string
+
+	// Compact drops the caller, the global tags and stack traces from every log,
+	// to minimize the cost and size of high volume logging.
+	Compact bool
+
+	// ErrorRateAlert calls back when too many ERROR and higher logs are emitted, nil disables it.
+	ErrorRateAlert *ErrorRateAlert
+
+	// Encoding overrides the encoding chosen from LOGGER_ENVIRONMENT: "json", "console", "csv" or "logfmt".
+	Encoding string
+
+	// CSVColumns are the columns of the "csv" encoding: "timestamp", "level", "message", "caller"
+	// or field names. Defaults to timestamp, level and message.
+	CSVColumns []string
+
+	// CSVHeader writes the column names as the first line of every CSV output.
+	CSVHeader bool
+
+	// NestDottedKeys expands dotted additional property keys into nested objects outside development,
+	// e.g. "http.request.method" is logged as {"http":{"request":{"method":...}}}.
+	NestDottedKeys bool
+
+	// Environment is logged as the environment tag of every log, e.g. "staging". Defaults to the
+	// ENVIRONMENT variable. It doesn't affect the formatting, which depends on LOGGER_ENVIRONMENT.
+	Environment string
+
+	// SequenceNumbers adds a seq field to every log, incremented atomically across the process,
+	// so downstream systems can detect lost or reordered logs.
+	SequenceNumbers bool
+
+	// CallerPackage adds a package field with the import path of the caller's package, next to
+	// the file:line caller.
+	CallerPackage bool
+
+	// NormalizeHTTPFields uppercases the Method of log messages and adds a status_text field with
+	// the text of the Status, e.g. "Not Found" for 404.
+	NormalizeHTTPFields bool
+
+	// Journald also sends the logs to journald over its native protocol, with their syslog PRIORITY
+	// and fields as structured journal fields. It's only supported on Linux.
+	Journald bool
+
+	// PayloadKey nests all the fields of every log under this single key, as a JSON string, for
+	// legacy parsers expecting the whole payload in one field such as "data".
+	PayloadKey string
+
+	// MaxFields caps the number of additional properties of a log, the others are dropped and counted
+	// in a fields_truncated field. Unlimited when zero.
+	MaxFields int
+
+	// TimeSource provides the corrected current time on hosts with clock skew. Encoded times are shifted
+	// by its offset from the local clock, including the ones set with entry.WithTimestamp.
+	TimeSource func() time.Time
+
+	// FlattenFields flattens the nested maps and structs of the additional properties into dotted keys,
+	// e.g. http.method=GET, for outputs that can't represent nested objects such as plain syslog.
+	FlattenFields bool
+
+	// LevelNameMap replaces the emitted name of levels, e.g. {"WARN": "WARNING"}. The keys are the level
+	// names accepted by LOG_LEVEL. Levels that aren't mapped keep zap's names.
+	LevelNameMap map[string]string
+
+	// DuplicateKeys is what WithField and WithFields do with a key that's already set: DuplicateKeysOverwrite
+	// (default) replaces the value, DuplicateKeysError keeps the first one and lists the key in a
+	// duplicate_keys field, DuplicateKeysAppend logs all the values as an array.
+	DuplicateKeys string
+
+	// RedactSQLArgs masks the arguments of the queries logged with entry.WithQuery.
+	RedactSQLArgs bool
+
+	// InterpolateSQLArgs logs the queries of entry.WithQuery with their arguments in place of
+	// the placeholders, in development only.
+	InterpolateSQLArgs bool
+
+	// BoolFormat renders the boolean additional properties as "yes" and "no" with BoolYesNo, or 1 and 0
+	// with BoolOneZero, instead of true and false.
+	BoolFormat string
+
+	// OrderedWrites writes the logs one at a time, so their order in every output matches their timestamps
+	// and seq numbers even with concurrent callers, e.g. for audit logs. It serializes all the logging.
+	OrderedWrites bool
+
+	// LoggerContextKey is the key of the LogMessage.LoggerContext field, "rosetta-context" by default.
+	// "-" leaves the field out.
+	LoggerContextKey string
+
+	// FieldCount adds a field_count field with the number of other fields of the log, nested objects
+	// counting as one, to monitor the cardinality of logs.
+	FieldCount bool
+
+	// CallerEncoder is how the caller is encoded: "short" (default) keeps the package and file,
+	// "full" the whole path, and "trimmed-N" the last N segments of the path.
+	CallerEncoder string
+
+	// Int64AsString encodes the latency and the 64-bit integer additional properties as strings in JSON
+	// when they are too large for JavaScript numbers, beyond 2^53-1, to preserve their precision.
+	Int64AsString bool
+
+	// CompactJSON guarantees newline delimited JSON, one log per line, whatever LOGGER_ENVIRONMENT and
+	// Encoding say, for ingestion pipelines that require it. Levels aren't colored and the fields stay
+	// structured in development too. Init fails when LevelEncodings holds another encoding.
+	CompactJSON bool
+
+	// SeverityNumber adds the syslog severity of the level as a severity_number field: 7 for DEBUG,
+	// 6 for INFO, 4 for WARN, 3 for ERROR and 2 for PANIC and FATAL.
+	SeverityNumber bool
+
+	// EmptyMessage replaces the empty messages, e.g. of pure fields access logs, such as "request".
+	EmptyMessage string
+
+	// OmitEmptyMessage leaves the message key out of the JSON logs with an empty message,
+	// instead of logging an empty string.
+	OmitEmptyMessage bool
+
+	// LevelEncodings overrides the encoding of the levels, e.g. {"INFO": "logfmt", "ERROR": "json"},
+	// with "json", "console", "csv" or "logfmt". It applies to the default outputs, not to the Sinks.
+	LevelEncodings map[string]string
+}
+
+// SamplingConfig logs the first Initial entries with the same level and key in every Tick,
+// and every Thereafter-th entry after that.
+type SamplingConfig struct {
+	Tick       time.Duration // one second when zero
+	Initial    int
+	Thereafter int
+	// KeyFunc groups log messages by a stable key, e.g. a route template, instead of the rendered message.
+	KeyFunc func(logMessage *LogMessage) string
+}
+
+var loggerConfig atomic.Value // *Config of the running logger, replaced with it, see getConfig
+
+// Init (re)builds the zap logger with the given configuration.
+// On error the previous logger and configuration are kept.
+func Init(cfg Config) error {
+	if err := buildZapLogger(cfg, ""); err != nil {
+		return err
+	}
+	initZapLoggerOnce.Do(func() {})
+	return nil
+}
+
+// getConfig provides the configuration of the running logger, which must not be modified.
+// It's the zero Config until the logger is built.
+func getConfig() *Config {
+	if cfg, ok := loggerConfig.Load().(*Config); ok {
+		return cfg
+	}
+	return &Config{}
+}
+
+// MustInit is like Init but panics if the logger can't be built, e.g. on an invalid configuration
+// or an output file that can't be opened, for services that must not start without logs.
+func MustInit(cfg Config) {
+	if err := Init(cfg); err != nil {
+		panic(fmt.Sprintf("logger: can't initialize the logger: %v", err))
+	}
+}
+
+// validateEnvironment checks that the logger environment variables and the encoding hold recognized values.
+func validateEnvironment(cfg Config) error {
+	if level := os.Getenv(LogLevel); level != "" {
+		if _, err := parseLogLevel(level); err != nil {
+			return errors.New(fmt.Sprintf("invalid %v: %v", LogLevel, err))
+		}
+	}
+
+	switch env := os.Getenv(LoggerEnvironment); env {
+	case "", development, dev, production, prod, staging:
+	default:
+		return errors.New(fmt.Sprintf("invalid %v: unknown logger environment %v", LoggerEnvironment, env))
+	}
+
+	switch cfg.Encoding {
+	case "", "json", "console", csvEncoding, logfmtEncoding:
+	default:
+		return errors.New(fmt.Sprintf("invalid encoding %v", cfg.Encoding))
+	}
+
+	return nil
+}
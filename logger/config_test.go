@@ -0,0 +1,47 @@
+package logger
+
+import "testing"
+
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable string
+		value    string
+		cfg      Config
+	}{
+		{name: "log level", variable: LogLevel, value: "LOUD"},
+		{name: "logger environment", variable: LoggerEnvironment, value: "MARS"},
+		{name: "encoding", cfg: Config{Encoding: "yaml"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initTestLogger(t, Config{})
+			if test.variable != "" {
+				setEnv(t, test.variable, test.value)
+			}
+
+			if err := Init(test.cfg); err != nil {
+				t.Errorf("lenient mode failed: %v", err)
+			}
+			test.cfg.Strict = true
+			if err := Init(test.cfg); err == nil {
+				t.Error("strict mode accepted the bad value")
+			}
+		})
+	}
+}
+
+func TestMustInitPanics(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer func() {
+		if recovered := recover(); recovered == nil {
+			t.Error("expected a panic for an unwritable path")
+		}
+		Info("still logged")
+		if entry := output.lastEntry(t); entry["msg"] != "still logged" {
+			t.Errorf("got %v", entry)
+		}
+	}()
+
+	MustInit(Config{Sinks: []Sink{{Path: "/nonexistent/directory/app.log", Level: InfoLevel}}})
+}
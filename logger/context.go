@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	skippedOnDoneContext uint64 // number of logs dropped because their context was done
+	logContextDeadline   uint32 // 1 to add the deadline fields of the entry's context, see SetLogContextDeadline
+)
+
+var (
+	skipOnDoneContextMutex sync.RWMutex
+	skipOnDoneContext      = map[zapcore.Level]bool{zapcore.DebugLevel: true} // levels dropped once the entry's context is done
+)
+
+var (
+	contextExtractorsMutex sync.RWMutex
+	contextExtractors      []ContextFieldExtractor // run on the context of every entry, see RegisterContextFieldExtractor
+)
+
+// ContextFieldExtractor derives a field from a context, e.g. a user id stored by an authentication
+// middleware. It returns false when the context doesn't hold the value.
+type ContextFieldExtractor func(ctx context.Context) (key string, value interface{}, ok bool)
+
+const (
+	deadlineRemainingMs = "deadline_remaining_ms"
+	deadlineExceeded    = "deadline_exceeded"
+)
+
+// WithContext creates an entry bound to ctx. Once ctx is cancelled or past its deadline, logs at the
+// levels configured with SetSkipOnDoneContext (DEBUG by default) are dropped.
+func WithContext(ctx context.Context) *entry {
+	newEntry := &entry{
+		value: make(Fields),
+	}
+
+	return newEntry.WithContext(ctx)
+}
+
+// WithContext binds the entry to ctx, see the package level WithContext.
+func (e *entry) WithContext(ctx context.Context) *entry {
+	e.ctx = ctx
+	return e
+}
+
+// skip reports whether a log at the given level must be dropped for this entry.
+func (e *entry) skip(level zapcore.Level) bool {
+	if e.hasMinLevel && level < e.minLevel {
+		return true
+	}
+	if e.ctx != nil && e.ctx.Err() != nil && isSkippedOnDoneContext(level) {
+		atomic.AddUint64(&skippedOnDoneContext, 1)
+		return true
+	}
+	return false
+}
+
+// addContextFields adds the fields derived from the entry's context to the log message.
+func (e *entry) addContextFields(logMessage *LogMessage) {
+	if e.ctx == nil {
+		return
+	}
+
+	contextExtractorsMutex.RLock()
+	extractors := contextExtractors
+	contextExtractorsMutex.RUnlock()
+	for _, extract := range extractors {
+		if key, value, ok := extract(e.ctx); ok {
+			if _, found := logMessage.AdditionalProperties[key]; !found {
+				logMessage.AdditionalProperties[key] = value
+			}
+		}
+	}
+
+	if atomic.LoadUint32(&logContextDeadline) == 1 {
+		if deadline, ok := e.ctx.Deadline(); ok {
+			logMessage.AdditionalProperties[deadlineRemainingMs] = time.Until(deadline).Milliseconds()
+			logMessage.AdditionalProperties[deadlineExceeded] = errors.Is(e.ctx.Err(), context.DeadlineExceeded)
+		}
+	}
+}
+
+// RegisterContextFieldExtractor adds functions run on the context of every entry created with WithContext,
+// to add fields from the context values. The fields set on the entry take precedence over the extracted ones.
+func RegisterContextFieldExtractor(extractors ...ContextFieldExtractor) {
+	contextExtractorsMutex.Lock()
+	defer contextExtractorsMutex.Unlock()
+	contextExtractors = append(contextExtractors, extractors...)
+}
+
+// SetLogContextDeadline enables the "deadline_remaining_ms" and "deadline_exceeded" fields on entries
+// whose context has a deadline, to see how much of the deadline a request consumed.
+func SetLogContextDeadline(enabled bool) {
+	var value uint32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreUint32(&logContextDeadline, value)
+}
+
+// SetSkipOnDoneContext sets the levels that are dropped for entries whose context is done.
+// Calling it without levels logs everything regardless of the context.
+func SetSkipOnDoneContext(levels ...string) error {
+	skipLevels := make(map[zapcore.Level]bool, len(levels))
+	for _, level := range levels {
+		zapLevel, err := parseLogLevel(level)
+		if err != nil {
+			return err
+		}
+		skipLevels[zapLevel] = true
+	}
+
+	skipOnDoneContextMutex.Lock()
+	defer skipOnDoneContextMutex.Unlock()
+	skipOnDoneContext = skipLevels
+	return nil
+}
+
+// isSkippedOnDoneContext reports whether the logs at the level are dropped for entries whose context is done.
+func isSkippedOnDoneContext(level zapcore.Level) bool {
+	skipOnDoneContextMutex.RLock()
+	defer skipOnDoneContextMutex.RUnlock()
+	return skipOnDoneContext[level]
+}
+
+// SkippedOnDoneContext returns how many logs were dropped because their context was done.
+func SkippedOnDoneContext() uint64 {
+	return atomic.LoadUint64(&skippedOnDoneContext)
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelledContextSkipsLevels(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer SetSkipOnDoneContext(DebugLevel)
+	if err := SetSkipOnDoneContext(DebugLevel, InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	skipped := SkippedOnDoneContext()
+
+	WithContext(ctx).Info("skipped")
+	WithContext(ctx).Warn("logged")
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "logged" {
+		t.Errorf("got %v", messages)
+	}
+	if SkippedOnDoneContext() != skipped+1 {
+		t.Errorf("%v logs were skipped", SkippedOnDoneContext()-skipped)
+	}
+}
+
+func TestContextDeadlineFields(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer SetLogContextDeadline(false)
+	SetLogContextDeadline(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	WithContext(ctx).Info("in time")
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	WithContext(expired).Info("too late")
+	WithContext(context.Background()).Info("no deadline")
+
+	entries := output.entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("got %v", entries)
+	}
+	if remaining, _ := entries[0][deadlineRemainingMs].(float64); remaining <= 0 || entries[0][deadlineExceeded] != false {
+		t.Errorf("got %v", entries[0])
+	}
+	if remaining, _ := entries[1][deadlineRemainingMs].(float64); remaining >= 0 || entries[1][deadlineExceeded] != true {
+		t.Errorf("got %v", entries[1])
+	}
+	if _, found := entries[2][deadlineRemainingMs]; found {
+		t.Errorf("got %v", entries[2])
+	}
+}
+
+type testContextKey string
+
+func TestContextFieldExtractors(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer func() { contextExtractors = nil }()
+	valueExtractor := func(key string) ContextFieldExtractor {
+		return func(ctx context.Context) (string, interface{}, bool) {
+			value, ok := ctx.Value(testContextKey(key)).(string)
+			return key, value, ok
+		}
+	}
+
+	RegisterContextFieldExtractor(valueExtractor("user"), valueExtractor("tenant"))
+	ctx := context.WithValue(context.Background(), testContextKey("user"), "alice")
+	ctx = context.WithValue(ctx, testContextKey("tenant"), "acme")
+	WithContext(ctx).Info("extracted")
+	WithContext(ctx).WithField("user", "bob").Info("overridden")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	if entries[0]["user"] != "alice" || entries[0]["tenant"] != "acme" {
+		t.Errorf("got %v", entries[0])
+	}
+	if entries[1]["user"] != "bob" || entries[1]["tenant"] != "acme" {
+		t.Errorf("got %v", entries[1])
+	}
+}
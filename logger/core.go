@@ -0,0 +1,376 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	source            = "source"
+	callerPackage     = "package"
+	severityNumber    = "severity_number"
+	timestampOverride = "timestamp-override" // skipped field carrying LogMessage.Timestamp to timestampOverrideCore
+	samplingKey       = "sampling-key"       // skipped field carrying the SamplingConfig.KeyFunc result to samplerCore
+)
+
+// wrapCore decorates a single output core with the optional behaviors enabled in the configuration.
+// It is applied to every core before they are combined, so each one still filters by its own level.
+func wrapCore(cfg Config, developmentEnv bool, core zapcore.Core) zapcore.Core {
+	if cfg.PayloadKey != "" {
+		core = &payloadCore{Core: core, key: cfg.PayloadKey}
+	}
+	core = &timestampOverrideCore{Core: core}
+	if cfg.Sampling != nil {
+		core = newSamplerCore(core, *cfg.Sampling)
+	}
+	if flushLevel, err := parseLogLevel(cfg.FlushLevel); cfg.FlushLevel != "" && err == nil {
+		core = &flushCore{Core: core, flushLevel: flushLevel}
+	}
+	if cfg.IncludeSourceSnippet && developmentEnv {
+		core = &sourceSnippetCore{Core: core}
+	}
+	if cfg.CallerPackage {
+		core = &callerPackageCore{Core: core}
+	}
+	if cfg.SeverityNumber {
+		core = &severityNumberCore{Core: core}
+	}
+	return core
+}
+
+// verboseCore writes the entries below the level of the main core to it anyway. It's only added for the
+// messages of verbose correlation ids, next to the cores of the logger, so the other outputs still filter
+// by their own level. See SetVerboseCorrelationIDs.
+type verboseCore struct {
+	zapcore.Core
+}
+
+func (c *verboseCore) Enabled(level zapcore.Level) bool {
+	// The enabled levels are already written by the main core itself.
+	return !c.Core.Enabled(level)
+}
+
+func (c *verboseCore) With(fields []zapcore.Field) zapcore.Core {
+	return &verboseCore{Core: c.Core.With(fields)}
+}
+
+func (c *verboseCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *verboseCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, fields)
+}
+
+// withVerboseCore returns a logger that also writes the levels its main core filters out, see verboseCore.
+func withVerboseCore(logger *zap.Logger) *zap.Logger {
+	main := getVerboseMainCore()
+	if main == nil {
+		return logger
+	}
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, &verboseCore{Core: main})
+	}))
+}
+
+// exitCore calls exitFunc when the entry is written. It's added last to the FATAL entries returned by Check,
+// so the entry is written to the other cores first.
+type exitCore struct{}
+
+func (exitCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c exitCore) With([]zapcore.Field) zapcore.Core {
+	return c
+}
+
+func (c exitCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checkedEntry.AddCore(entry, c)
+}
+
+func (exitCore) Write(zapcore.Entry, []zapcore.Field) error {
+	getExitFunc()(1)
+	return nil
+}
+
+func (exitCore) Sync() error {
+	return nil
+}
+
+// payloadCore replaces the fields with a single field holding them all as a JSON string.
+// The skipped control fields are kept as is for the wrapping cores.
+type payloadCore struct {
+	zapcore.Core
+	key    string
+	fields []zapcore.Field // fields added with With, encoded in the payload too
+}
+
+func (c *payloadCore) With(fields []zapcore.Field) zapcore.Core {
+	return &payloadCore{Core: c.Core, key: c.key, fields: append(append([]zapcore.Field{}, c.fields...), fields...)}
+}
+
+func (c *payloadCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *payloadCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	var controlFields []zapcore.Field
+	// The fields of c are shared with the concurrent writes, so they aren't appended to in place.
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(append(all, c.fields...), fields...)
+	for _, field := range all {
+		if field.Type == zapcore.SkipType {
+			controlFields = append(controlFields, field)
+			continue
+		}
+		field.AddTo(encoder)
+	}
+
+	payload, err := json.Marshal(encoder.Fields)
+	if err != nil {
+		return err
+	}
+	return c.Core.Write(entry, append(controlFields, zap.String(c.key, string(payload))))
+}
+
+// callerPackageCore attaches the import path of the caller's package.
+type callerPackageCore struct {
+	zapcore.Core
+}
+
+func (c *callerPackageCore) With(fields []zapcore.Field) zapcore.Core {
+	return &callerPackageCore{Core: c.Core.With(fields)}
+}
+
+func (c *callerPackageCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *callerPackageCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Caller.Defined {
+		if fn := runtime.FuncForPC(entry.Caller.PC); fn != nil {
+			fields = append(fields, zap.String(callerPackage, packageOfFunction(fn.Name())))
+		}
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// packageOfFunction strips the function, and receiver, from a fully qualified function name
+// such as "github.com/org/repo/pkg.(*Type).Method". The runtime escapes the dots of the last path
+// element, as in "gopkg.in/yaml%2ev2.Unmarshal", which are restored.
+func packageOfFunction(name string) string {
+	lastSlash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[lastSlash+1:], "."); dot >= 0 {
+		name = name[:lastSlash+1+dot]
+	}
+	return strings.Replace(name, "%2e", ".", -1)
+}
+
+// syslogSeverities are the syslog severities of the levels, from 0 (emergency) to 7 (debug).
+var syslogSeverities = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  2,
+	zapcore.FatalLevel:  2,
+}
+
+// severityNumberCore attaches the syslog severity of the level, for tools sorting by numeric severity.
+type severityNumberCore struct {
+	zapcore.Core
+}
+
+func (c *severityNumberCore) With(fields []zapcore.Field) zapcore.Core {
+	return &severityNumberCore{Core: c.Core.With(fields)}
+}
+
+func (c *severityNumberCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *severityNumberCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, append(fields, zap.Int(severityNumber, syslogSeverities[entry.Level])))
+}
+
+// sourceSnippetCore attaches the source code line of the caller to ERROR and higher entries.
+type sourceSnippetCore struct {
+	zapcore.Core
+}
+
+func (c *sourceSnippetCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sourceSnippetCore{Core: c.Core.With(fields)}
+}
+
+func (c *sourceSnippetCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *sourceSnippetCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level >= zapcore.ErrorLevel && entry.Caller.Defined {
+		if line, ok := readSourceLine(entry.Caller.File, entry.Caller.Line); ok {
+			fields = append(fields, zap.String(source, line))
+		}
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// readSourceLine reads a single line from a source file. Any error reading the file is ignored.
+func readSourceLine(file string, line int) (string, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for current := 1; scanner.Scan(); current++ {
+		if current == line {
+			return strings.TrimSpace(scanner.Text()), true
+		}
+	}
+	return "", false
+}
+
+// timestampOverrideCore replaces the entry time with the one carried by the timestamp override field.
+type timestampOverrideCore struct {
+	zapcore.Core
+}
+
+func (c *timestampOverrideCore) With(fields []zapcore.Field) zapcore.Core {
+	return &timestampOverrideCore{Core: c.Core.With(fields)}
+}
+
+func (c *timestampOverrideCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *timestampOverrideCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	for _, field := range fields {
+		if field.Key == timestampOverride && field.Type == zapcore.SkipType {
+			if timestamp, ok := field.Interface.(time.Time); ok {
+				entry.Time = timestamp
+			}
+		}
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// samplerCore drops repeated entries sharing the same level and sampling key within a tick.
+type samplerCore struct {
+	zapcore.Core
+	sampling *samplingCounts
+}
+
+// samplingCounts is shared by a samplerCore and the cores derived from it with With.
+type samplingCounts struct {
+	sync.Mutex
+	config    SamplingConfig
+	windowEnd time.Time
+	counts    map[string]int
+}
+
+func newSamplerCore(core zapcore.Core, config SamplingConfig) *samplerCore {
+	if config.Tick <= 0 {
+		config.Tick = time.Second
+	}
+	return &samplerCore{Core: core, sampling: &samplingCounts{config: config, counts: make(map[string]int)}}
+}
+
+func (c *samplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplerCore{Core: c.Core.With(fields), sampling: c.sampling}
+}
+
+func (c *samplerCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *samplerCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	key := entry.Message
+	for _, field := range fields {
+		if field.Key == samplingKey && field.Type == zapcore.SkipType {
+			key = field.String
+		}
+	}
+	if !c.sampling.allow(entry.Level.String()+"|"+key, entry.Time) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// allow counts an entry for key and reports whether it is sampled in.
+func (s *samplingCounts) allow(key string, now time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if now.After(s.windowEnd) {
+		s.counts = make(map[string]int)
+		s.windowEnd = now.Add(s.config.Tick)
+	}
+	s.counts[key]++
+	n := s.counts[key]
+
+	if n <= s.config.Initial {
+		return true
+	}
+	return s.config.Thereafter > 0 && (n-s.config.Initial)%s.config.Thereafter == 0
+}
+
+// flushCore syncs the wrapped core right after writing entries at or above flushLevel.
+type flushCore struct {
+	zapcore.Core
+	flushLevel zapcore.Level
+}
+
+func (c *flushCore) With(fields []zapcore.Field) zapcore.Core {
+	return &flushCore{Core: c.Core.With(fields), flushLevel: c.flushLevel}
+}
+
+func (c *flushCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *flushCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(entry, fields); err != nil {
+		return err
+	}
+	if entry.Level >= c.flushLevel {
+		return c.Core.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSourceSnippet(t *testing.T) {
+	setEnv(t, LoggerEnvironment, development)
+	output := initTestLogger(t, Config{Encoding: "json", IncludeSourceSnippet: true})
+
+	Error("failed with a snippet")
+
+	if entry := output.lastEntry(t); entry[source] != `Error("failed with a snippet")` {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestTimestampOverride(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	timestamp := time.Date(2020, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+	logMessage := New()
+	logMessage.Message = "replayed"
+	logMessage.Timestamp = timestamp
+	InfoMessage(logMessage)
+
+	if entry := output.lastEntry(t); entry[timeStamp] != timestamp.Format(UtcTimeFormat) {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestSamplingKeyFunc(t *testing.T) {
+	output := initTestLogger(t, Config{Sampling: &SamplingConfig{
+		Tick:    time.Minute,
+		Initial: 1,
+		KeyFunc: func(logMessage *LogMessage) string { return logMessage.Path },
+	}})
+
+	for _, message := range []string{"GET /users/1", "GET /users/2"} {
+		logMessage := New()
+		logMessage.Message = message
+		logMessage.Path = "/users/:id"
+		InfoMessage(logMessage)
+	}
+	Info("another key")
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "GET /users/1,another key" {
+		t.Errorf("got %v", messages)
+	}
+}
+
+func TestFlushLevel(t *testing.T) {
+	output := initTestLogger(t, Config{FlushLevel: ErrorLevel})
+
+	Info("buffered")
+	if syncs := output.syncCount(); syncs != 0 {
+		t.Errorf("INFO log synced %v times", syncs)
+	}
+
+	Error("flushed")
+	if syncs := output.syncCount(); syncs == 0 {
+		t.Error("ERROR log wasn't synced")
+	}
+}
+
+func TestCallerPackage(t *testing.T) {
+	output := initTestLogger(t, Config{CallerPackage: true})
+
+	Info("from the logger package")
+
+	if entry := output.lastEntry(t); entry[callerPackage] != "github.com/mritunjaykumar/logger/logger" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestPackageOfFunction(t *testing.T) {
+	tests := map[string]string{
+		"github.com/org/repo/pkg.(*Type).Method": "github.com/org/repo/pkg",
+		"github.com/org/repo/pkg.Function.func1": "github.com/org/repo/pkg",
+		"gopkg.in/yaml%2ev2.Unmarshal":           "gopkg.in/yaml.v2",
+		"main.main":                              "main",
+	}
+	for name, expected := range tests {
+		if pkg := packageOfFunction(name); pkg != expected {
+			t.Errorf("got %v for %v, want %v", pkg, name, expected)
+		}
+	}
+}
+
+func TestPayloadKey(t *testing.T) {
+	output := initTestLogger(t, Config{PayloadKey: "data"})
+
+	WithFields(Fields{"user": "alice", "attempts": 3}).Info("nested")
+
+	entry := output.lastEntry(t)
+	if _, found := entry["user"]; found {
+		t.Errorf("got %v", entry)
+	}
+	payload, _ := entry["data"].(string)
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		t.Fatalf("payload %q isn't JSON: %v", payload, err)
+	}
+	if fields["user"] != "alice" || fields["attempts"] != float64(3) {
+		t.Errorf("got %v", fields)
+	}
+}
+
+func TestPayloadKeyConcurrentWrites(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	// The spare capacity of the shared fields is where concurrent appends would collide.
+	shared := make([]zapcore.Field, 1, 4)
+	shared[0] = zap.String("shared", "yes")
+	core := &payloadCore{Core: observed, key: "data", fields: shared}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(writer string) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				core.Write(zapcore.Entry{Message: writer}, []zapcore.Field{zap.String("writer", writer)})
+			}
+		}(fmt.Sprint(i))
+	}
+	wg.Wait()
+
+	for _, entry := range logs.All() {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(entry.ContextMap()["data"].(string)), &fields); err != nil {
+			t.Fatal(err)
+		}
+		if fields["writer"] != entry.Message || fields["shared"] != "yes" {
+			t.Fatalf("got %v for the writer %v", fields, entry.Message)
+		}
+	}
+}
+
+func TestTimeSource(t *testing.T) {
+	output := initTestLogger(t, Config{TimeSource: func() time.Time { return time.Now().Add(time.Hour) }})
+
+	Info("corrected")
+
+	logged, err := time.Parse(UtcTimeFormat, output.lastEntry(t)[timeStamp].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew := logged.Sub(time.Now()); skew < 59*time.Minute || skew > time.Hour {
+		t.Errorf("logged time is %v ahead", skew)
+	}
+}
+
+func TestSeverityNumber(t *testing.T) {
+	setEnv(t, LogLevel, DebugLevel)
+	output := initTestLogger(t, Config{SeverityNumber: true})
+
+	DebugMessage(&LogMessage{Message: "debug"}) // Debug is a no-op with the logger_nodebug tag
+	Info("info")
+	Warn("warn")
+	Error("error")
+
+	entries := output.entries(t)
+	if len(entries) != 4 {
+		t.Fatalf("got %v", entries)
+	}
+	for i, expected := range []float64{7, 6, 4, 3} {
+		if entries[i][severityNumber] != expected {
+			t.Errorf("got severity %v for %v, want %v", entries[i][severityNumber], entries[i]["level"], expected)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	csvEncoding = "csv"
+	csvLevel    = "level"
+	csvMessage  = "message"
+	csvCaller   = "caller"
+)
+
+var csvBufferPool = buffer.NewPool()
+
+// csvEncoder writes every entry as a CSV row with a fixed set of columns: "timestamp", "level", "message",
+// "caller" or the name of a field. Missing fields are left empty. The time and level are formatted by the
+// encoder configuration, like in the other encodings.
+type csvEncoder struct {
+	*zapcore.MapObjectEncoder
+	encoderConfig zapcore.EncoderConfig
+	columns       []string
+	header        bool
+	headerOnce    *sync.Once
+}
+
+func newCSVEncoder(encoderConfig zapcore.EncoderConfig, columns []string, header bool) *csvEncoder {
+	if len(columns) == 0 {
+		columns = []string{timeStamp, csvLevel, csvMessage}
+	}
+	return &csvEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		encoderConfig:    encoderConfig,
+		columns:          columns,
+		header:           header,
+		headerOnce:       &sync.Once{},
+	}
+}
+
+func (e *csvEncoder) Clone() zapcore.Encoder {
+	clone := &csvEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		encoderConfig:    e.encoderConfig,
+		columns:          e.columns,
+		header:           e.header,
+		headerOnce:       e.headerOnce,
+	}
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+func (e *csvEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fieldValues := e.Clone().(*csvEncoder)
+	for _, field := range fields {
+		field.AddTo(fieldValues)
+	}
+
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		switch column {
+		case timeStamp:
+			row[i] = encodeTime(e.encoderConfig, entry.Time)
+		case csvLevel:
+			row[i] = encodeLevel(e.encoderConfig, entry.Level)
+		case csvMessage:
+			row[i] = entry.Message
+		case csvCaller:
+			if entry.Caller.Defined {
+				row[i] = entry.Caller.TrimmedPath()
+			}
+		default:
+			if value, ok := fieldValues.Fields[column]; ok {
+				row[i] = csvValue(value)
+			}
+		}
+	}
+
+	line := csvBufferPool.Get()
+	writer := csv.NewWriter(line)
+	if e.header {
+		e.headerOnce.Do(func() {
+			writer.Write(e.columns)
+		})
+	}
+	writer.Write(row)
+	writer.Flush()
+	return line, writer.Error()
+}
+
+// csvValue formats a field value for a CSV cell. Objects and arrays are written as JSON.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// encodeTime formats the time with the EncodeTime of the encoder configuration, in UTC when it has none.
+func encodeTime(encoderConfig zapcore.EncoderConfig, t time.Time) string {
+	if encoderConfig.EncodeTime == nil {
+		return t.UTC().Format(UtcTimeFormat)
+	}
+	return encodedString(func(enc zapcore.PrimitiveArrayEncoder) {
+		encoderConfig.EncodeTime(t, enc)
+	})
+}
+
+// encodeLevel formats the level with the EncodeLevel of the encoder configuration, in capitals when it has none.
+func encodeLevel(encoderConfig zapcore.EncoderConfig, level zapcore.Level) string {
+	if encoderConfig.EncodeLevel == nil {
+		return level.CapitalString()
+	}
+	return encodedString(func(enc zapcore.PrimitiveArrayEncoder) {
+		encoderConfig.EncodeLevel(level, enc)
+	})
+}
+
+// encodedString runs one of zap's primitive encoders such as EncodeTime, for encoders that write plain text.
+func encodedString(encode func(enc zapcore.PrimitiveArrayEncoder)) string {
+	values := zapcore.NewMapObjectEncoder()
+	values.AddArray("", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		encode(enc)
+		return nil
+	}))
+
+	var encoded strings.Builder
+	for _, value := range values.Fields[""].([]interface{}) {
+		encoded.WriteString(csvValue(value))
+	}
+	return encoded.String()
+}
@@ -0,0 +1,27 @@
+package logger
+
+import "testing"
+
+func TestCSVEncoding(t *testing.T) {
+	output := initTestLogger(t, Config{
+		Encoding:   csvEncoding,
+		CSVColumns: []string{csvLevel, csvMessage, "user", "missing"},
+		CSVHeader:  true,
+	})
+
+	WithField("user", `alice "al" smith`).Info("signed in, finally")
+
+	lines := output.lines()
+	expected := []string{
+		"level,message,user,missing",
+		`info,"signed in, finally","alice ""al"" smith",`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("got %q", lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("got %q, want %q", lines[i], expected[i])
+		}
+	}
+}
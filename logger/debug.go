@@ -0,0 +1,39 @@
+//go:build !logger_nodebug
+// +build !logger_nodebug
+
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// The DEBUG level functions are in their own file so that building with the logger_nodebug tag
+// replaces them with no-ops, see debug_nodebug.go.
+
+func (e *entry) Debug(msg string) {
+	if e.skip(zapcore.DebugLevel) {
+		return
+	}
+	debugMessage(e.storeFields(msg))
+}
+
+func (e *entry) Debugf(format string, args ...interface{}) {
+	if e.skip(zapcore.DebugLevel) {
+		return
+	}
+	debugMessage(e.storeFields(fmt.Sprintf(format, args...)))
+}
+
+func Debug(args ...interface{}) {
+	debugMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
+
+func Debugf(format string, args ...interface{}) {
+	debugMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
+}
+
+func Debugln(args ...interface{}) {
+	debugMessage(&LogMessage{Message: sprintln(args...)})
+}
@@ -0,0 +1,17 @@
+//go:build logger_nodebug
+// +build logger_nodebug
+
+package logger
+
+// With the logger_nodebug build tag the DEBUG level functions are no-ops the compiler can inline away,
+// so hot paths don't even pay for the level check. Their arguments are still evaluated.
+
+func (e *entry) Debug(msg string) {}
+
+func (e *entry) Debugf(format string, args ...interface{}) {}
+
+func Debug(args ...interface{}) {}
+
+func Debugf(format string, args ...interface{}) {}
+
+func Debugln(args ...interface{}) {}
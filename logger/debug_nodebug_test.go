@@ -0,0 +1,21 @@
+//go:build logger_nodebug
+// +build logger_nodebug
+
+package logger
+
+import "testing"
+
+func TestDebugNoop(t *testing.T) {
+	setEnv(t, LogLevel, DebugLevel)
+	output := initTestLogger(t, Config{})
+
+	Debug("dropped")
+	Debugf("dropped %v", 1)
+	Debugln("dropped")
+	WithField("key", "value").Debug("dropped")
+	WithField("key", "value").Debugf("dropped %v", 1)
+
+	if lines := output.lines(); len(lines) != 0 {
+		t.Errorf("got %v", lines)
+	}
+}
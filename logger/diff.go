@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+const changes = "changes"
+
+// WithDiff adds the fields that differ between two versions of an object under changes, each with its
+// old and new values, e.g. {"changes":{"email":{"old":"a@x.io","new":"b@x.io"}}}. The objects are compared
+// as they're encoded in JSON, so struct fields are named by their JSON name. A nil or non-object version
+// counts as an object without fields, so creations and deletions list every field. The registered
+// redacted paths apply to the changed fields as if they were logged directly: "ssn" masks both values
+// of the ssn field and "address.street" the street within them.
+func (e *entry) WithDiff(old, new interface{}) *entry {
+	oldFields, newFields := diffFields(old), diffFields(new)
+
+	diff := make(map[string]interface{})
+	for key, oldValue := range oldFields {
+		if newValue := newFields[key]; !reflect.DeepEqual(oldValue, newValue) {
+			diff[key] = diffChange(key, oldValue, newValue)
+		}
+	}
+	for key, newValue := range newFields {
+		if _, found := oldFields[key]; !found {
+			diff[key] = diffChange(key, nil, newValue)
+		}
+	}
+
+	e.value[changes] = diff
+	return e
+}
+
+// diffFields decodes the JSON representation of an object into its fields.
+func diffFields(object interface{}) map[string]interface{} {
+	var fields map[string]interface{}
+	if encoded, err := json.Marshal(object); err == nil {
+		json.Unmarshal(encoded, &fields)
+	}
+	return fields
+}
+
+// diffChange pairs the old and new values of a changed field, masking the registered redacted paths.
+func diffChange(key string, oldValue, newValue interface{}) map[string]interface{} {
+	redactedPathsMutex.RLock()
+	paths := redactedPaths
+	redactedPathsMutex.RUnlock()
+
+	for _, path := range paths {
+		if path[0] != key {
+			continue
+		}
+		oldValue, newValue = redactDiffValue(oldValue, path), redactDiffValue(newValue, path)
+	}
+	return map[string]interface{}{"old": oldValue, "new": newValue}
+}
+
+// redactDiffValue masks the path in the value of a changed field, leaving missing values as null.
+func redactDiffValue(value interface{}, path []string) interface{} {
+	switch {
+	case value == nil:
+		return nil
+	case len(path) == 1:
+		return redactedValue
+	default:
+		return redactJSONPath(value, path[1:])
+	}
+}
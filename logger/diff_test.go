@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithDiff(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer func() { redactedPaths = nil }()
+	type user struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		SSN   string `json:"ssn,omitempty"`
+	}
+
+	RegisterRedactedPath("ssn")
+	WithFields(Fields{}).WithDiff(
+		user{Name: "alice", Email: "a@x.io"},
+		user{Name: "alice", Email: "b@x.io", SSN: "123-45-6789"},
+	).Info("updated user")
+
+	line := output.String()
+	for _, expected := range []string{
+		`"email":{"new":"b@x.io","old":"a@x.io"}`,
+		`"ssn":{"new":"` + redactedValue + `","old":null}`,
+	} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("%q doesn't contain %v", line, expected)
+		}
+	}
+	if strings.Contains(line, `"name"`) || strings.Contains(line, "123-45-6789") {
+		t.Errorf("got %q", line)
+	}
+}
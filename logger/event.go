@@ -0,0 +1,15 @@
+package logger
+
+const event = "event"
+
+// Event sets the event field, a stable name such as "user.created" that consumers can group by
+// independently of the message wording.
+func (e *entry) Event(name string) *entry {
+	e.value[event] = name
+	return e
+}
+
+// LogEvent logs msg at INFO level with the fields and the event field set to name.
+func LogEvent(name, msg string, fields Fields) {
+	infoMessage(WithFields(fields).Event(name).storeFields(msg))
+}
@@ -0,0 +1,21 @@
+package logger
+
+import "testing"
+
+func TestEvent(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	LogEvent("user.created", "created user alice", Fields{"user": "alice"})
+	WithField("user", "bob").Event("user.deleted").Warn("deleted user bob")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	if entry := entries[0]; entry[event] != "user.created" || entry["user"] != "alice" || entry["level"] != "info" {
+		t.Errorf("got %v", entry)
+	}
+	if entry := entries[1]; entry[event] != "user.deleted" || entry["user"] != "bob" {
+		t.Errorf("got %v", entry)
+	}
+}
@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const (
+	requestHeaders  = "request_headers"
+	responseHeaders = "response_headers"
+	requestSize     = "request_size"
+	responseSize    = "response_size"
+	panicValue      = "panic"
+	panicStack      = "stack"
+	statusText      = "status_text"
+	redactedValue   = "[REDACTED]"
+)
+
+// DefaultRedactedHeaders are the sensitive headers redacted when HTTPOptions.RedactedHeaders is nil.
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie"}
+
+// HTTPOptions configures HTTPMiddleware.
+type HTTPOptions struct {
+	// RequestHeaders and ResponseHeaders are allowlists of header names logged as nested objects under
+	// "request_headers" and "response_headers". Nothing is logged by default.
+	RequestHeaders  []string
+	ResponseHeaders []string
+
+	// RedactedHeaders are logged as [REDACTED] even when they are allowlisted, DefaultRedactedHeaders
+	// when nil. Like any field, headers can also be masked with RegisterRedactedPath, e.g.
+	// RegisterRedactedPath("request_headers.X-Api-Key").
+	RedactedHeaders []string
+
+	// RecoverPanics recovers from panics in the handler, logs them at ERROR level with their stack,
+	// or FATAL with FatalOnPanic, and responds with a 500 when nothing was written yet.
+	// http.ErrAbortHandler isn't recovered, so the server still aborts the response.
+	RecoverPanics bool
+	FatalOnPanic  bool
+}
+
+// statusRecorder captures the status code and the number of body bytes written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// flushRecorder forwards Flush to the wrapped writer, for streaming responses such as server-sent events.
+type flushRecorder struct{ *statusRecorder }
+
+func (r flushRecorder) Flush() {
+	r.wroteHeader = true
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijackRecorder hands the connection of the wrapped writer over to the handler, e.g. for websockets.
+type hijackRecorder struct{ *statusRecorder }
+
+func (r hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// pushRecorder forwards HTTP/2 server pushes to the wrapped writer.
+type pushRecorder struct{ *statusRecorder }
+
+func (r pushRecorder) Push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// responseWriter returns the recorder implementing the same optional interfaces as the wrapped writer,
+// so the handler can still check which ones it supports.
+func (r *statusRecorder) responseWriter() http.ResponseWriter {
+	_, canFlush := r.ResponseWriter.(http.Flusher)
+	_, canHijack := r.ResponseWriter.(http.Hijacker)
+	_, canPush := r.ResponseWriter.(http.Pusher)
+	flusher, hijacker, pusher := flushRecorder{r}, hijackRecorder{r}, pushRecorder{r}
+
+	switch {
+	case canFlush && canHijack && canPush:
+		return struct {
+			*statusRecorder
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{r, flusher, hijacker, pusher}
+	case canFlush && canHijack:
+		return struct {
+			*statusRecorder
+			http.Flusher
+			http.Hijacker
+		}{r, flusher, hijacker}
+	case canFlush && canPush:
+		return struct {
+			*statusRecorder
+			http.Flusher
+			http.Pusher
+		}{r, flusher, pusher}
+	case canHijack && canPush:
+		return struct {
+			*statusRecorder
+			http.Hijacker
+			http.Pusher
+		}{r, hijacker, pusher}
+	case canFlush:
+		return struct {
+			*statusRecorder
+			http.Flusher
+		}{r, flusher}
+	case canHijack:
+		return struct {
+			*statusRecorder
+			http.Hijacker
+		}{r, hijacker}
+	case canPush:
+		return struct {
+			*statusRecorder
+			http.Pusher
+		}{r, pusher}
+	default:
+		return r
+	}
+}
+
+// Unwrap gives http.ResponseController access to the wrapped writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// countingReader counts the request body bytes read by the wrapped handler, when the size is unknown upfront.
+type countingReader struct {
+	io.ReadCloser
+	size int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// HTTPMiddleware logs every request served by next with the built-in HTTP fields of LogMessage, and the
+// request_size and response_size in bytes. The request size is the Content-Length, or the number of body
+// bytes read by next when it's unknown, e.g. for chunked requests.
+func HTTPMiddleware(options HTTPOptions, next http.Handler) http.Handler {
+	redactedHeaders := options.RedactedHeaders
+	if redactedHeaders == nil {
+		redactedHeaders = DefaultRedactedHeaders
+	}
+	redacted := make(map[string]bool, len(redactedHeaders))
+	for _, name := range redactedHeaders {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		var body *countingReader
+		if r.ContentLength < 0 && r.Body != nil {
+			body = &countingReader{ReadCloser: r.Body}
+			r.Body = body
+		}
+		logMessage := New()
+		start := time.Now()
+
+		// logRequest logs the served request, at ERROR or FATAL level when the handler panicked.
+		logRequest := func() {
+			end := time.Now()
+			logMessage.Method = r.Method
+			logMessage.Path = r.URL.Path
+			logMessage.Query = r.URL.RawQuery
+			logMessage.Protocol = r.Proto
+			logMessage.ClientIP = clientIPFromRemoteAddr(r.RemoteAddr)
+			logMessage.UserAgent = r.UserAgent()
+			logMessage.Status = recorder.status
+			logMessage.StartTime = start.UTC()
+			logMessage.EndTime = end.UTC()
+			logMessage.LatencyNanoSeconds = end.Sub(start).Nanoseconds()
+			if body != nil {
+				logMessage.AdditionalProperties[requestSize] = body.size
+			} else {
+				logMessage.AdditionalProperties[requestSize] = r.ContentLength
+			}
+			logMessage.AdditionalProperties[responseSize] = recorder.size
+			if headers := allowlistedHeaders(r.Header, options.RequestHeaders, redacted); len(headers) > 0 {
+				logMessage.AdditionalProperties[requestHeaders] = headers
+			}
+			if headers := allowlistedHeaders(recorder.Header(), options.ResponseHeaders, redacted); len(headers) > 0 {
+				logMessage.AdditionalProperties[responseHeaders] = headers
+			}
+
+			if _, panicked := logMessage.AdditionalProperties[panicValue]; !panicked {
+				InfoMessage(logMessage)
+			} else if options.FatalOnPanic {
+				FatalMessage(logMessage)
+			} else {
+				ErrorMessage(logMessage)
+			}
+		}
+
+		if options.RecoverPanics {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					// The handler aborted the response on purpose, which the server handles silently.
+					panic(recovered)
+				}
+				if !recorder.wroteHeader {
+					recorder.WriteHeader(http.StatusInternalServerError)
+				}
+				logMessage.AdditionalProperties[panicValue] = fmt.Sprint(recovered)
+				logMessage.AdditionalProperties[panicStack] = string(debug.Stack())
+				logRequest()
+			}()
+		}
+
+		next.ServeHTTP(recorder.responseWriter(), r)
+
+		logRequest()
+	})
+}
+
+// withNormalizedHTTPFields returns a copy of the log message with the method uppercased and the
+// status_text of the status added, or the message itself when it has neither or normalization is off.
+func withNormalizedHTTPFields(logMessage *LogMessage) *LogMessage {
+	if !getConfig().NormalizeHTTPFields || (logMessage.Method == "" && logMessage.Status == 0) {
+		return logMessage
+	}
+
+	normalized := *logMessage
+	normalized.Method = strings.ToUpper(logMessage.Method)
+	if text := http.StatusText(logMessage.Status); text != "" {
+		normalized.AdditionalProperties = make(map[string]interface{}, len(logMessage.AdditionalProperties)+1)
+		for k, v := range logMessage.AdditionalProperties {
+			normalized.AdditionalProperties[k] = v
+		}
+		normalized.AdditionalProperties[statusText] = text
+	}
+	return &normalized
+}
+
+// allowlistedHeaders picks the allowlisted headers that are present, redacting the given canonical names.
+func allowlistedHeaders(header http.Header, allowlist []string, redacted map[string]bool) map[string]string {
+	headers := make(map[string]string)
+	for _, name := range allowlist {
+		name = http.CanonicalHeaderKey(name)
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		if redacted[name] {
+			value = redactedValue
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// clientIPFromRemoteAddr strips the port from the request's remote address.
+func clientIPFromRemoteAddr(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
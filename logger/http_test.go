@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPMiddlewareHeaders(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	handler := HTTPMiddleware(HTTPOptions{RequestHeaders: []string{"X-Tenant", "Authorization"}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set("X-Tenant", "acme")
+	request.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	headers, _ := output.lastEntry(t)[requestHeaders].(map[string]interface{})
+	if headers["X-Tenant"] != "acme" || headers["Authorization"] != redactedValue {
+		t.Errorf("got %v", headers)
+	}
+}
+
+func TestHTTPMiddlewareRedactedHeaders(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	handler := HTTPMiddleware(HTTPOptions{RequestHeaders: []string{"X-Api-Key"}, RedactedHeaders: []string{"x-api-key"}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set("X-Api-Key", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	headers, _ := output.lastEntry(t)[requestHeaders].(map[string]interface{})
+	if headers["X-Api-Key"] != redactedValue {
+		t.Errorf("got %v", headers)
+	}
+}
+
+func TestHTTPMiddlewareForwardsFlusher(t *testing.T) {
+	initTestLogger(t, Config{})
+	handler := HTTPMiddleware(HTTPOptions{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("the response writer isn't a Flusher")
+		}
+		w.Write([]byte("event"))
+		flusher.Flush()
+		if _, ok := w.(http.Hijacker); ok {
+			t.Error("the response writer is a Hijacker, unlike the recorder it wraps")
+		}
+		if _, ok := w.(http.Pusher); ok {
+			t.Error("the response writer is a Pusher, unlike the recorder it wraps")
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if !recorder.Flushed {
+		t.Error("the response wasn't flushed")
+	}
+}
+
+// hijackableWriter is a response writer that only supports hijacking.
+type hijackableWriter struct {
+	http.ResponseWriter
+	hijacked bool
+}
+
+func (w *hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestHTTPMiddlewareForwardsHijacker(t *testing.T) {
+	initTestLogger(t, Config{})
+	handler := HTTPMiddleware(HTTPOptions{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); ok {
+			t.Error("the response writer is a Flusher, unlike the writer it wraps")
+		}
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("the response writer isn't a Hijacker")
+		}
+		hijacker.Hijack()
+	}))
+
+	writer := &hijackableWriter{ResponseWriter: httptest.NewRecorder()}
+	handler.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/socket", nil))
+
+	if !writer.hijacked {
+		t.Error("the connection wasn't hijacked")
+	}
+}
+
+func TestHTTPMiddlewareSizes(t *testing.T) {
+	handler := HTTPMiddleware(HTTPOptions{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte("created"))
+	}))
+
+	tests := []struct {
+		name          string
+		contentLength int64
+	}{
+		{name: "content length", contentLength: 11},
+		{name: "chunked", contentLength: -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := initTestLogger(t, Config{})
+
+			request := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id":"42"}`))
+			request.ContentLength = test.contentLength
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			if entry := output.lastEntry(t); entry[requestSize] != float64(11) || entry[responseSize] != float64(7) {
+				t.Errorf("got %v", entry)
+			}
+		})
+	}
+}
+
+func TestHTTPMiddlewareRecoverPanics(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	handler := HTTPMiddleware(HTTPOptions{RecoverPanics: true}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("responded with %v", recorder.Code)
+	}
+	entry := output.lastEntry(t)
+	if entry["level"] != "error" || entry[panicValue] != "boom" || entry[status] != float64(http.StatusInternalServerError) {
+		t.Errorf("got %v", entry)
+	}
+	if stack, _ := entry[panicStack].(string); !strings.Contains(stack, "TestHTTPMiddlewareRecoverPanics") {
+		t.Errorf("got stack %q", stack)
+	}
+}
+
+func TestHTTPMiddlewareAbortHandler(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	handler := HTTPMiddleware(HTTPOptions{RecoverPanics: true}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("got the panic %v", recovered)
+		}
+		if lines := output.lines(); len(lines) != 0 {
+			t.Errorf("logged %v", lines)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+}
+
+func TestNormalizeHTTPFields(t *testing.T) {
+	output := initTestLogger(t, Config{NormalizeHTTPFields: true})
+
+	logMessage := New()
+	logMessage.Message = "served"
+	logMessage.Method = "get"
+	logMessage.Status = http.StatusNotFound
+	InfoMessage(logMessage)
+
+	if entry := output.lastEntry(t); entry[method] != "GET" || entry[statusText] != "Not Found" {
+		t.Errorf("got %v", entry)
+	}
+	if logMessage.Method != "get" || len(logMessage.AdditionalProperties) != 0 {
+		t.Errorf("the logged message was modified: %+v", logMessage)
+	}
+}
@@ -0,0 +1,23 @@
+// Package loggerhooks gives the loggertest package access to internals of the logger package,
+// without exporting them to its users. The logger package sets the hooks when it's initialized.
+package loggerhooks
+
+import "go.uber.org/zap/zapcore"
+
+var (
+	// ReplaceCore logs through the core instead of the configured outputs, wrapped like the cores built
+	// by Init, until restore puts back the previous logger.
+	ReplaceCore func(core zapcore.Core) (restore func())
+
+	// WithCore creates an entry logging through the core, wrapped like the cores built by Init, instead of
+	// the package logger. The entry type isn't exported, so it's returned as an interface{} holding a
+	// logger.Logger.
+	WithCore func(core zapcore.Core) interface{}
+
+	// TeeCore also writes every log to the core, next to the configured outputs, until restore puts back
+	// the previous logger.
+	TeeCore func(core zapcore.Core) (restore func())
+
+	// ParseLevel parses a level name such as "WARN" into its zap level.
+	ParseLevel func(level string) (zapcore.Level, error)
+)
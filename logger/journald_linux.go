@@ -0,0 +1,139 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldCore sends logs to journald over its native protocol, with the level mapped to the
+// syslog PRIORITY and the fields as structured journal fields.
+type journaldCore struct {
+	zapcore.LevelEnabler
+	conn   *net.UnixConn
+	fields []zapcore.Field
+}
+
+// newJournaldCore connects to journald, returning the core and the function closing its connection.
+func newJournaldCore(level zapcore.LevelEnabler) (zapcore.Core, func(), error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to journald: %w", err)
+	}
+	return &journaldCore{LevelEnabler: level, conn: conn}, func() { conn.Close() }, nil
+}
+
+func (c *journaldCore) With(fields []zapcore.Field) zapcore.Core {
+	return &journaldCore{
+		LevelEnabler: c.LevelEnabler,
+		conn:         c.conn,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+func (c *journaldCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
+
+func (c *journaldCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	// The fields of c are shared with the concurrent writes, so they aren't appended to in place.
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(append(all, c.fields...), fields...)
+	for _, field := range all {
+		field.AddTo(encoder)
+	}
+
+	var datagram bytes.Buffer
+	appendJournaldField(&datagram, "MESSAGE", entry.Message)
+	appendJournaldField(&datagram, "PRIORITY", strconv.Itoa(journaldPriority(entry.Level)))
+	appendJournaldField(&datagram, "SYSLOG_IDENTIFIER", getComponentName())
+	if entry.Caller.Defined {
+		appendJournaldField(&datagram, "CODE_FILE", entry.Caller.File)
+		appendJournaldField(&datagram, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+	}
+	if entry.Stack != "" {
+		appendJournaldField(&datagram, "STACKTRACE", entry.Stack)
+	}
+	for key, value := range encoder.Fields {
+		text, ok := value.(string)
+		if !ok {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				encoded = []byte(fmt.Sprint(value))
+			}
+			text = string(encoded)
+		}
+		appendJournaldField(&datagram, journaldFieldName(key), text)
+	}
+
+	_, err := c.conn.Write(datagram.Bytes())
+	return err
+}
+
+func (c *journaldCore) Sync() error {
+	return nil
+}
+
+// journaldPriority maps a level to its syslog priority.
+func journaldPriority(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7 // debug
+	case zapcore.InfoLevel:
+		return 6 // info
+	case zapcore.WarnLevel:
+		return 4 // warning
+	case zapcore.ErrorLevel:
+		return 3 // err
+	default:
+		return 2 // crit
+	}
+}
+
+// journaldFieldName turns a field key into a valid journal field name: uppercase letters, digits and
+// underscores, not starting with an underscore or a digit.
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if name == "" || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		name = "F" + name
+	}
+	return name
+}
+
+// appendJournaldField appends a field in the journal export format, with the length-prefixed
+// form for values spanning several lines.
+func appendJournaldField(datagram *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(datagram, "%v=%v\n", name, value)
+		return
+	}
+	datagram.WriteString(name)
+	datagram.WriteByte('\n')
+	binary.Write(datagram, binary.LittleEndian, uint64(len(value)))
+	datagram.WriteString(value)
+	datagram.WriteByte('\n')
+}
@@ -0,0 +1,106 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestJournaldPriority(t *testing.T) {
+	tests := map[zapcore.Level]int{
+		zapcore.DebugLevel:  7,
+		zapcore.InfoLevel:   6,
+		zapcore.WarnLevel:   4,
+		zapcore.ErrorLevel:  3,
+		zapcore.DPanicLevel: 2,
+		zapcore.PanicLevel:  2,
+		zapcore.FatalLevel:  2,
+	}
+	for level, expected := range tests {
+		if priority := journaldPriority(level); priority != expected {
+			t.Errorf("got priority %v for %v, want %v", priority, level, expected)
+		}
+	}
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":      "USER_ID",
+		"http.method":  "HTTP_METHOD",
+		"_private":     "F_PRIVATE",
+		"2fa":          "F2FA",
+		"correlation-": "CORRELATION_",
+	}
+	for key, expected := range tests {
+		if name := journaldFieldName(key); name != expected {
+			t.Errorf("got %v for %v, want %v", name, key, expected)
+		}
+	}
+}
+
+func TestJournaldConcurrentWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journald")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	addr := &net.UnixAddr{Name: filepath.Join(dir, "socket"), Net: "unixgram"}
+	journal, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The spare capacity of the shared fields is where concurrent appends would collide.
+	shared := make([]zapcore.Field, 1, 4)
+	shared[0] = zap.String("shared", "yes")
+	core := &journaldCore{LevelEnabler: zapcore.DebugLevel, conn: conn, fields: shared}
+
+	// The datagram queue of the socket is short, so the journal reads while the writers write.
+	const writes = 20
+	datagrams := make(chan string)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := journal.Read(buf)
+			if err != nil {
+				close(datagrams)
+				return
+			}
+			datagrams <- string(buf[:n])
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		go func(writer string) {
+			for j := 0; j < writes; j++ {
+				if err := core.Write(zapcore.Entry{Message: writer}, []zapcore.Field{zap.String("writer", writer)}); err != nil {
+					t.Error(err)
+				}
+			}
+		}(fmt.Sprint(i))
+	}
+
+	for i := 0; i < 2*writes; i++ {
+		datagram := <-datagrams
+		message := strings.SplitN(strings.TrimPrefix(datagram, "MESSAGE="), "\n", 2)[0]
+		if !strings.Contains(datagram, "WRITER="+message+"\n") || !strings.Contains(datagram, "SHARED=yes\n") {
+			t.Errorf("got %q", datagram)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package logger
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func newJournaldCore(level zapcore.LevelEnabler) (zapcore.Core, func(), error) {
+	return nil, nil, errors.New("journald is only supported on Linux")
+}
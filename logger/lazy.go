@@ -0,0 +1,33 @@
+package logger
+
+// WithLazy adds a field computed by fn only when the log is emitted, so expensive values such as
+// serialized objects aren't computed for logs suppressed by the level.
+func (e *entry) WithLazy(key string, fn func() interface{}) *entry {
+	if e.lazy == nil {
+		e.lazy = make(map[string]func() interface{})
+	}
+	e.lazy[key] = fn
+	return e
+}
+
+// withLazyFields returns a copy of the log message with its lazy fields computed when its level is
+// enabled, or the message itself when it has none.
+func withLazyFields(logMessage *LogMessage, enabled bool) *LogMessage {
+	if len(logMessage.lazyFields) == 0 {
+		return logMessage
+	}
+
+	computed := *logMessage
+	computed.lazyFields = nil
+	if !enabled {
+		return &computed
+	}
+	computed.AdditionalProperties = make(map[string]interface{}, len(logMessage.AdditionalProperties)+len(logMessage.lazyFields))
+	for k, v := range logMessage.AdditionalProperties {
+		computed.AdditionalProperties[k] = v
+	}
+	for k, fn := range logMessage.lazyFields {
+		computed.AdditionalProperties[k] = fn()
+	}
+	return &computed
+}
@@ -0,0 +1,39 @@
+package logger
+
+import "testing"
+
+func TestWithLazy(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	calls := 0
+	dump := func() interface{} {
+		calls++
+		return "expensive"
+	}
+
+	WithFields(Fields{}).WithLazy("dump", dump).Debug("suppressed")
+	if calls != 0 {
+		t.Errorf("computed %v times for a suppressed log", calls)
+	}
+	WithFields(Fields{}).WithLazy("dump", dump).Info("emitted")
+	if calls != 1 {
+		t.Errorf("computed %v times for an emitted log", calls)
+	}
+
+	if entry := output.lastEntry(t); entry["dump"] != "expensive" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func BenchmarkWithLazySuppressed(b *testing.B) {
+	initBenchmarkLogger(b, Config{})
+	dump := func() interface{} {
+		b.Fatal("computed for a suppressed log")
+		return nil
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		WithFields(Fields{}).WithLazy("dump", dump).Debug("suppressed")
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// parseLevelEncodings parses Config.LevelEncodings, checking its levels and encodings.
+func parseLevelEncodings(encodings map[string]string) (map[zapcore.Level]string, error) {
+	parsed := make(map[zapcore.Level]string, len(encodings))
+	for levelName, encoding := range encodings {
+		level, err := parseLogLevel(levelName)
+		if err != nil {
+			return nil, err
+		}
+		switch encoding {
+		case "json", "console", csvEncoding, logfmtEncoding:
+		default:
+			return nil, errors.New(fmt.Sprintf("unknown encoding %v for level %v", encoding, levelName))
+		}
+		parsed[level] = encoding
+	}
+	return parsed, nil
+}
+
+// buildLevelEncodingCores creates a core per level with its own encoding, writing to the default outputs.
+// It also returns the function closing the outputs it opened.
+func buildLevelEncodingCores(cfg Config, encodings map[zapcore.Level]string, zapConfig zap.Config) ([]zapcore.Core, func(), error) {
+	if len(encodings) == 0 {
+		return nil, func() {}, nil
+	}
+	writer, closeWriter, err := zap.Open(zapConfig.OutputPaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cores := make([]zapcore.Core, 0, len(encodings))
+	for level, encoding := range encodings {
+		level := level
+		enabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l == level && zapConfig.Level.Enabled(l)
+		})
+		cores = append(cores, zapcore.NewCore(newEncoder(cfg, encoding, zapConfig.EncoderConfig), writer, enabler))
+	}
+	return cores, closeWriter, nil
+}
+
+// excludedLevelsCore leaves the levels with their own encoding to their cores, see buildLevelEncodingCores.
+type excludedLevelsCore struct {
+	zapcore.Core
+	levels map[zapcore.Level]string
+}
+
+func (c *excludedLevelsCore) Enabled(level zapcore.Level) bool {
+	_, excluded := c.levels[level]
+	return !excluded && c.Core.Enabled(level)
+}
+
+func (c *excludedLevelsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &excludedLevelsCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *excludedLevelsCore) Check(entry zapcore.Entry, checkedEntry *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checkedEntry.AddCore(entry, c)
+	}
+	return checkedEntry
+}
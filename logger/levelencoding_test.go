@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLevelEncodings(t *testing.T) {
+	output := initTestLogger(t, Config{LevelEncodings: map[string]string{InfoLevel: logfmtEncoding, ErrorLevel: "json"}})
+
+	WithField("user", "alice").Info("signed in")
+	Error("failed")
+
+	lines := output.lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %q", lines)
+	}
+	if !strings.Contains(lines[0], "level=info") || !strings.Contains(lines[0], `msg="signed in"`) || !strings.Contains(lines[0], "user=alice") {
+		t.Errorf("got %q for INFO", lines[0])
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("ERROR line %q isn't JSON: %v", lines[1], err)
+	}
+	if entry["msg"] != "failed" || entry["stacktrace"] == nil {
+		t.Errorf("got %v for ERROR", entry)
+	}
+}
+
+func TestLevelEncodingsWithCompactJSON(t *testing.T) {
+	initTestLogger(t, Config{})
+
+	if err := Init(Config{CompactJSON: true, LevelEncodings: map[string]string{InfoLevel: logfmtEncoding}}); err == nil {
+		t.Error("expected an error for a logfmt level with CompactJSON")
+	}
+}
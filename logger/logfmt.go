@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const logfmtEncoding = "logfmt"
+
+var logfmtBufferPool = buffer.NewPool()
+
+// logfmtEncoder writes every entry as a line of key=value pairs: the time, level, caller, message
+// and stack trace under the keys of the encoder configuration, which also formats the time and level,
+// then the fields sorted by key.
+// Objects and arrays are written as JSON.
+type logfmtEncoder struct {
+	*zapcore.MapObjectEncoder
+	encoderConfig zapcore.EncoderConfig
+}
+
+func newLogfmtEncoder(encoderConfig zapcore.EncoderConfig) *logfmtEncoder {
+	return &logfmtEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), encoderConfig: encoderConfig}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := newLogfmtEncoder(e.encoderConfig)
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	fieldValues := e.Clone().(*logfmtEncoder)
+	for _, field := range fields {
+		field.AddTo(fieldValues)
+	}
+
+	line := logfmtBufferPool.Get()
+	appendPair := func(key, value string) {
+		if key == "" {
+			return
+		}
+		if line.Len() > 0 {
+			line.AppendByte(' ')
+		}
+		line.AppendString(key)
+		line.AppendByte('=')
+		line.AppendString(logfmtValue(value))
+	}
+
+	appendPair(e.encoderConfig.TimeKey, encodeTime(e.encoderConfig, entry.Time))
+	appendPair(e.encoderConfig.LevelKey, encodeLevel(e.encoderConfig, entry.Level))
+	if entry.Caller.Defined {
+		appendPair(e.encoderConfig.CallerKey, entry.Caller.TrimmedPath())
+	}
+	appendPair(e.encoderConfig.MessageKey, entry.Message)
+	if entry.Stack != "" {
+		appendPair(e.encoderConfig.StacktraceKey, entry.Stack)
+	}
+
+	keys := make([]string, 0, len(fieldValues.Fields))
+	for k := range fieldValues.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		appendPair(k, csvValue(fieldValues.Fields[k]))
+	}
+
+	line.AppendString(zapcore.DefaultLineEnding)
+	return line, nil
+}
+
+// logfmtValue quotes the values that are empty or contain spaces, quotes, equal signs or control characters.
+func logfmtValue(value string) string {
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == 0x7f
+	}) >= 0 {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,6 +19,10 @@ import (
 const (
 	application   = "astra"
 	correlationId = "correlation-id"
+	traceID       = "trace-id"
+	spanID        = "span-id"
+	requestID     = "request-id"
+	tenantID      = "tenant-id"
 	clientIp      = "client-ip"
 	endTime       = "end-time"
 	latency       = "latency"
@@ -23,7 +30,11 @@ const (
 	loggerContext = "rosetta-context"
 	method        = "method"
 	nilLogMessage = "rosetta is called with nil log message"
+	indentUnit    = "  "
 	ns            = "ns"
+	us            = "us"
+	ms            = "ms"
+	sec           = "s"
 	path          = "path"
 	protocol      = "protocol"
 	query         = "query"
@@ -32,6 +43,11 @@ const (
 	timeStamp     = "timestamp"
 	userAgent     = "user-agent"
 	UtcTimeFormat = "2006-01-02T15:04:05.000000Z0700"
+	seq           = "seq"
+	truncated     = "fields_truncated"
+	fieldCount    = "field_count"
+
+	callerSkipOffset = 4 // public function, level wrapper, callZapLogger and writeZapEntry
 
 	// Supported log levels
 	LogLevel     = "LOG_LEVEL"
@@ -42,22 +58,80 @@ const (
 	ErrorLevel   = "ERROR"
 	FatalLevel   = "FATAL"
 
+	// NilMessageIgnore makes nil log messages a silent no-op, see SetNilMessageLevel.
+	NilMessageIgnore = "IGNORE"
+
 	LoggerEnvironment = "LOGGER_ENVIRONMENT"
 	development       = "DEVELOPMENT"
 	dev               = "DEV"
+	production        = "PRODUCTION"
+	prod              = "PROD"
+	staging           = "STAGING"
 	logOutputFile     = "LOG_OUTPUT_FILE"
+	ComponentName     = "COMPONENT_NAME"
+
+	// Environment is the variable of the deployment environment tag, e.g. "staging", "prod" or "qa",
+	// unrelated to the formatting chosen with LOGGER_ENVIRONMENT. See Config.Environment.
+	Environment = "ENVIRONMENT"
+
+	// EnvTagPrefix prefixes the variables added as global tags, named after the rest of the variable
+	// in lower case, e.g. LOGTAG_REGION=us-east adds region: us-east. They're read when the logger is built.
+	EnvTagPrefix = "LOGTAG_"
 )
 
 var (
-	zapLogger         *zap.Logger            // zap logger instance based on the zapLogger environment and other config settings
-	logEnv            string                 // logger environment (DEV or non-dev (PROD, STAGING or anything else)
+	zapLogger         atomic.Value           // *zap.Logger based on the logger environment and other config settings, swapped by tests while logging
+	logEnv            atomic.Value           // string logger environment (DEV or non-dev (PROD, STAGING or anything else)
 	logLvl            = zap.NewAtomicLevel() // Dynamic log level
 	initZapLoggerOnce sync.Once
 	NoStacktrace      string
+	sequence          uint64 // last seq field value, see Config.SequenceNumbers
+
+	envTags atomic.Value // map[string]string of the global tags read from the environment, see EnvTagPrefix
+
+	globalTagFieldsMutex sync.RWMutex
+	globalTagFields      []zap.Field // zap fields of the global tags, see getGlobalTagFields
+	globalTagEnvironment string      // environment tag of globalTagFields
+
+	orderedWritesMutex sync.Mutex // held while writing an entry, see Config.OrderedWrites
+
+	fieldAllowlistMutex sync.RWMutex
+	fieldAllowlist      map[string]bool // additional property keys emitted outside development, all when empty
+
+	componentNameMutex sync.RWMutex
+	componentName      string // value of the component tag, see getComponentName
+	componentNameOnce  sync.Once
+
+	versionInfoMutex sync.RWMutex
+	versionInfo      map[string]string // version, commit and build_time tags, see SetVersionInfo
+
+	exitFuncMutex sync.RWMutex
+	exitFunc      = os.Exit // called with 1 after FATAL logs, see SetExitFunc
+
+	quietErrorsMutex sync.RWMutex
+	quietErrors      []error // errors logged without stack trace by entry.WithError
+
+	nilMessageMutex  sync.RWMutex
+	nilMessageLevel  = zapcore.ErrorLevel // level of the log emitted for a nil log message
+	ignoreNilMessage bool                 // silently ignore nil log messages
+
+	transformersMutex sync.RWMutex
+	transformers      []func(string) string // applied in order to every message before it's logged
+
+	verboseMutex          sync.RWMutex
+	verboseCorrelationIDs map[string]bool // correlation ids logged at every level, see SetVerboseCorrelationIDs
+	verboseMainCore       zapcore.Core    // main output core of the logger, written at every level by verboseCore
+
+	zapLoggerSwapMutex sync.Mutex  // serializes the replacements of zapLogger with the closing of their outputs
+	closeOutputs       = func() {} // closes the outputs opened for the running logger, see buildZapLogger
+	callerSkip         int32       // caller frames skipped on top of the internal offset, changed with the logger
 )
 
-// UTC time encode
+// UTC time encode, corrected by the offset between Config.TimeSource and the local clock when set
 func utcTimeEncode(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+	if getConfig().TimeSource != nil {
+		t = t.Add(getConfig().TimeSource().Sub(time.Now()))
+	}
 	enc.AppendString(t.UTC().Format(UtcTimeFormat))
 }
 
@@ -69,22 +143,97 @@ func utcTimeEncode(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 //		- LOG_OUTPUT_FILE. If it's not empty, it will create a log file with that name and start writing logs
 // 						   to log file.
 //		- LOG_LEVEL. Supported log levels are DEBUG, INFO, WARN, ERROR, PANIC and FATAL
+//		- LOG_LOKI_URL. If it's not empty, logs are also pushed to that Loki push API URL in batches,
+//						labelled with the global tags.
+//		- LOGTAG_*. Every variable with this prefix is added as a global tag, see EnvTagPrefix.
 // Make sure we are creating ONLY one instance of zapLogger.
 func GetZapLogger() *zap.Logger {
 	initZapLoggerOnce.Do(func() {
-		buildZapLogger("")
+		if err := buildZapLogger(*getConfig(), ""); err != nil {
+			panic(err)
+		}
 	})
-	return zapLogger
+	return zapLogger.Load().(*zap.Logger)
 }
 
-func buildZapLogger(memoryOutputPathName string) {
-	const callerSkipOffset = 3
-	zapConfig := getConfigBasedOnLoggerEnvironment()
+// Check returns a CheckedEntry if logging a message at the given level is enabled, and nil otherwise.
+// It doesn't allocate when the level is disabled, which makes it suitable for hot paths:
+//
+//	if ce := logger.Check(logger.DebugLevel, "message"); ce != nil {
+//		ce.Write(zap.String("key", "value"))
+//	}
+//
+// Fields written this way are passed to zap as is, without the global tags. Writing a FATAL entry
+// calls the function set with SetExitFunc, os.Exit by default.
+func Check(level string, msg string) *zapcore.CheckedEntry {
+	zapLevel, err := parseLogLevel(level)
+	if err != nil || !GetZapLogger().Core().Enabled(zapLevel) {
+		return nil
+	}
+	// Only this function sits between the caller and zap, instead of the full internal call chain.
+	checkedEntry := GetZapLogger().WithOptions(zap.AddCallerSkip(1-callerSkipOffset)).Check(zapLevel, msg)
+	if checkedEntry != nil && zapLevel == zapcore.FatalLevel {
+		// zap calls os.Exit after writing FATAL entries, the last core exits with exitFunc instead.
+		checkedEntry = checkedEntry.Should(checkedEntry.Entry, zapcore.WriteThenNoop)
+		checkedEntry = checkedEntry.AddCore(checkedEntry.Entry, exitCore{})
+	}
+	return checkedEntry
+}
 
-	logLvl = zapConfig.Level // Initial log-level
+func buildZapLogger(cfg Config, memoryOutputPathName string) (err error) {
+	if cfg.Strict {
+		if err := validateEnvironment(cfg); err != nil {
+			return err
+		}
+	}
+	if _, err := latencyUnitDivisor(cfg.LatencyUnit); err != nil {
+		return err
+	}
+	if cfg.FlushLevel != "" {
+		if _, err := parseLogLevel(cfg.FlushLevel); err != nil {
+			return err
+		}
+	}
+	switch cfg.DuplicateKeys {
+	case "", DuplicateKeysOverwrite, DuplicateKeysError, DuplicateKeysAppend:
+	default:
+		return errors.New(fmt.Sprintf("unknown duplicate keys policy %v", cfg.DuplicateKeys))
+	}
+	switch cfg.BoolFormat {
+	case "", BoolYesNo, BoolOneZero:
+	default:
+		return errors.New(fmt.Sprintf("unknown bool format %v", cfg.BoolFormat))
+	}
+	names, err := parseLevelNames(cfg.LevelNameMap)
+	if err != nil {
+		return err
+	}
+	encodeCaller, err := parseCallerEncoder(cfg.CallerEncoder)
+	if err != nil {
+		return err
+	}
+	levelEncodings, err := parseLevelEncodings(cfg.LevelEncodings)
+	if err != nil {
+		return err
+	}
+	if cfg.CompactJSON {
+		for level, encoding := range levelEncodings {
+			if encoding != "json" {
+				return errors.New(fmt.Sprintf("CompactJSON requires json, not %v, as the encoding for level %v", encoding, level.CapitalString()))
+			}
+		}
+	}
+	environment := os.Getenv(LoggerEnvironment)
+	developmentEnv := environment == development || environment == dev
+	zapConfig := getConfigBasedOnLoggerEnvironment(environment)
 
 	// override log-level if LOG_LEVEL env variable is set
-	setLogLevelFromEnvironment()
+	level := zapConfig.Level.Level()
+	if zapLevel, err := parseLogLevel(os.Getenv(LogLevel)); err == nil {
+		level = zapLevel
+	}
+	// Every logger shares the package level, so the level handed out by AtomicLevel stays in effect.
+	zapConfig.Level = logLvl
 
 	zapConfig.EncoderConfig.EncodeTime = utcTimeEncode
 	zapConfig.EncoderConfig.TimeKey = timeStamp
@@ -97,16 +246,199 @@ func buildZapLogger(memoryOutputPathName string) {
 	}
 
 	zapConfig.Sampling = nil
-	var err error
-	if zapLogger, err = zapConfig.Build(zap.AddCallerSkip(callerSkipOffset)); err != nil {
-		panic(err)
+	if cfg.Encoding != "" {
+		zapConfig.Encoding = cfg.Encoding
+	}
+	if cfg.Compact {
+		zapConfig.DisableCaller = true
+		zapConfig.DisableStacktrace = true
+	}
+
+	if cfg.CompactJSON {
+		zapConfig.Encoding = "json"
+		zapConfig.EncoderConfig.LineEnding = zapcore.DefaultLineEnding
+		zapConfig.EncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+	}
+	if cfg.OmitEmptyMessage && zapConfig.Encoding == "json" {
+		zapConfig.Encoding = omitEmptyMessageEncoding
+	}
+
+	zapConfig.EncoderConfig.EncodeLevel = withLevelNames(zapConfig.EncoderConfig.EncodeLevel, names)
+	if encodeCaller != nil {
+		zapConfig.EncoderConfig.EncodeCaller = encodeCaller
+	}
+
+	// The outputs opened so far are closed when a later step fails, the running logger keeps its own.
+	var closers []func()
+	defer func() {
+		if err != nil {
+			closeAll(closers)()
+		}
+	}()
+
+	sinkCores, closeSinks, err := buildSinkCores(cfg, zapConfig.Encoding, zapConfig.EncoderConfig)
+	if err != nil {
+		return err
+	}
+	closers = append(closers, closeSinks)
+	if cfg.Journald {
+		journald, closeJournald, err := newJournaldCore(zapConfig.Level)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, closeJournald)
+		sinkCores = append(sinkCores, journald)
+	}
+	if url := os.Getenv(logLokiURL); url != "" {
+		loki := getLokiSink(url)
+		sinkCores = append(sinkCores, zapcore.NewCore(newEncoder(cfg, zapConfig.Encoding, zapConfig.EncoderConfig), loki, zapConfig.Level))
+	}
+	levelEncodingCores, closeLevelEncodingOutputs, err := buildLevelEncodingCores(cfg, levelEncodings, zapConfig)
+	if err != nil {
+		return err
+	}
+	closers = append(closers, closeLevelEncodingOutputs)
+	captureEncoder := newEncoder(cfg, zapConfig.Encoding, zapConfig.EncoderConfig)
+	if cfg.FullLineColor && developmentEnv {
+		useFullLineColor(&zapConfig, names)
+	}
+
+	var mainCore zapcore.Core
+	options := []zap.Option{
+		zap.AddCallerSkip(callerSkipOffset),
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			if len(levelEncodingCores) > 0 {
+				core = &excludedLevelsCore{Core: core, levels: levelEncodings}
+			}
+			cores := append([]zapcore.Core{core}, sinkCores...)
+			cores = append(cores, levelEncodingCores...)
+			for i := range cores {
+				cores[i] = wrapCore(cfg, developmentEnv, cores[i])
+			}
+			mainCore = cores[0]
+			// The capture core is wrapped on its own, so nothing is encoded for it unless a capture is active.
+			cores = append(cores, newCaptureCore(captureEncoder, zapConfig.Level, func(core zapcore.Core) zapcore.Core {
+				return wrapCore(cfg, developmentEnv, core)
+			}))
+			return zapcore.NewTee(cores...)
+		}),
+	}
+	if cfg.ErrorRateAlert != nil {
+		options = append(options, zap.Hooks(newErrorRateTracker(*cfg.ErrorRateAlert).hook))
+	}
+
+	newLogger, closeLoggerOutputs, err := newZapLogger(cfg, zapConfig, options...)
+	if err != nil {
+		return err
+	}
+	closers = append(closers, closeLoggerOutputs)
+
+	// The state of the running logger only changes once the new one is built, so a failed Init keeps it.
+	loggerConfig.Store(&cfg)
+	logEnv.Store(environment)
+	logLvl.SetLevel(level)
+	setVerboseMainCore(mainCore)
+	envTags.Store(getEnvTags())
+	resetGlobalTagFields() // the environment tag depends on the configuration
+
+	// The outputs of the previous logger are only closed once it's replaced. Logs written concurrently
+	// through it may still fail, and are reported to its error output.
+	// The caller skip is added while locked, so a concurrent AddCallerSkip applies to the new logger too.
+	zapLoggerSwapMutex.Lock()
+	closePreviousOutputs := closeOutputs
+	closeOutputs = closeAll(closers)
+	zapLogger.Store(newLogger.WithOptions(zap.AddCallerSkip(getCallerSkip())))
+	zapLoggerSwapMutex.Unlock()
+	closePreviousOutputs()
+	return nil
+}
+
+// closeAll provides a function calling all the close functions, in reverse order.
+func closeAll(closers []func()) func() {
+	return func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+}
+
+// newZapLogger builds the logger of zapConfig like zap.Config.Build does, with the encoders of newEncoder,
+// which get their settings from cfg instead of package variables. It also returns the function closing
+// its outputs.
+func newZapLogger(cfg Config, zapConfig zap.Config, options ...zap.Option) (*zap.Logger, func(), error) {
+	output, closeOutput, err := zap.Open(zapConfig.OutputPaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	errorOutput, closeErrorOutput, err := zap.Open(zapConfig.ErrorOutputPaths...)
+	if err != nil {
+		closeOutput()
+		return nil, nil, err
+	}
+
+	buildOptions := []zap.Option{zap.ErrorOutput(errorOutput)}
+	stackLevel := zapcore.ErrorLevel
+	if zapConfig.Development {
+		buildOptions = append(buildOptions, zap.Development())
+		stackLevel = zapcore.WarnLevel
+	}
+	if !zapConfig.DisableCaller {
+		buildOptions = append(buildOptions, zap.AddCaller())
+	}
+	if !zapConfig.DisableStacktrace {
+		buildOptions = append(buildOptions, zap.AddStacktrace(stackLevel))
 	}
+
+	core := zapcore.NewCore(newEncoder(cfg, zapConfig.Encoding, zapConfig.EncoderConfig), output, zapConfig.Level)
+	return zap.New(core, buildOptions...).WithOptions(options...), closeAll([]func(){closeOutput, closeErrorOutput}), nil
 }
 
-func getConfigBasedOnLoggerEnvironment() zap.Config {
-	logEnv = os.Getenv(LoggerEnvironment)
+// buildSinkCores opens every configured sink and wraps it in a core that only accepts
+// entries at or above the sink's own level. It also returns the function closing the sinks,
+// which are already closed when it fails.
+func buildSinkCores(cfg Config, encoding string, encoderConfig zapcore.EncoderConfig) ([]zapcore.Core, func(), error) {
+	cores := make([]zapcore.Core, 0, len(cfg.Sinks))
+	closers := make([]func(), 0, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		level, err := parseLogLevel(sink.Level)
+		if err != nil {
+			closeAll(closers)()
+			return nil, nil, err
+		}
+		writer, closeWriter, err := zap.Open(sink.Path)
+		if err != nil {
+			closeAll(closers)()
+			return nil, nil, err
+		}
+		closers = append(closers, closeWriter)
+		cores = append(cores, zapcore.NewCore(newEncoder(cfg, encoding, encoderConfig), writer, level))
+	}
+	return cores, closeAll(closers), nil
+}
+
+// newEncoder creates the zap encoder matching the configured encoding name.
+func newEncoder(cfg Config, encoding string, encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	switch encoding {
+	case "console":
+		return zapcore.NewConsoleEncoder(encoderConfig)
+	case fullLineColorEncoding:
+		return &fullLineColorEncoder{Encoder: zapcore.NewConsoleEncoder(encoderConfig)}
+	case csvEncoding:
+		return newCSVEncoder(encoderConfig, cfg.CSVColumns, cfg.CSVHeader)
+	case omitEmptyMessageEncoding:
+		return newOmitEmptyMessageEncoder(encoderConfig)
+	case logfmtEncoding:
+		return newLogfmtEncoder(encoderConfig)
+	default:
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+}
+
+// getConfigBasedOnLoggerEnvironment provides zap's configuration for the LOGGER_ENVIRONMENT value,
+// with its default level.
+func getConfigBasedOnLoggerEnvironment(environment string) zap.Config {
 	var zapConfig zap.Config
-	if logEnv == development || logEnv == dev {
+	if environment == development || environment == dev {
 		zapConfig = zap.NewDevelopmentConfig()
 		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	} else {
@@ -115,9 +447,36 @@ func getConfigBasedOnLoggerEnvironment() zap.Config {
 	return zapConfig
 }
 
-func setLogLevelFromEnvironment() {
-	// We are ignoring error returned by the below function call
-	setLogLevel(os.Getenv(LogLevel))
+// watchSignalForLevel re-reads LOG_LEVEL every time sig is received, until stop is called.
+// The changes are logged like any other log, through Warnf.
+func watchSignalForLevel(sig os.Signal) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, sig)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+			}
+			GetZapLogger().Sync()
+			previous := getLogLevel().String()
+			if err := setLogLevel(os.Getenv(LogLevel)); err != nil {
+				Warnf("cannot reload log level on signal %v: %v", sig, err)
+				continue
+			}
+			Warnf("log level changed from %v to %v on signal %v", previous, getLogLevel().String(), sig)
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
 }
 
 // AddStacktrace configures the Logger to record a stack trace for all messages at or above a given level.
@@ -130,43 +489,303 @@ func addStackTrace(logLevel string) {
 
 	if nst {
 		fmt.Println("going to disable stacktrace")
-		zapLogger = GetZapLogger().WithOptions()
+		zapLogger.Store(GetZapLogger().WithOptions())
 		return
 	}
 
 	switch logLevel {
 	case DebugLevel:
-		zapLogger = GetZapLogger().WithOptions(zap.AddStacktrace(zap.DebugLevel))
+		zapLogger.Store(GetZapLogger().WithOptions(zap.AddStacktrace(zap.DebugLevel)))
 	case InfoLevel:
-		zapLogger = GetZapLogger().WithOptions(zap.AddStacktrace(zap.InfoLevel))
+		zapLogger.Store(GetZapLogger().WithOptions(zap.AddStacktrace(zap.InfoLevel)))
 	case WarnLevel, WarningLevel:
-		zapLogger = GetZapLogger().WithOptions(zap.AddStacktrace(zap.WarnLevel))
+		zapLogger.Store(GetZapLogger().WithOptions(zap.AddStacktrace(zap.WarnLevel)))
 	case ErrorLevel:
-		zapLogger = GetZapLogger().WithOptions(zap.AddStacktrace(zap.ErrorLevel))
+		zapLogger.Store(GetZapLogger().WithOptions(zap.AddStacktrace(zap.ErrorLevel)))
 	default:
 		fmt.Println(errors.New(fmt.Sprintf("Cannot add stack trace for level %v", logLevel)))
 	}
 }
 
 func setLogLevel(level string) error {
+	zapLevel, err := parseLogLevel(level)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unknown log level %v, so log level in not set", level))
+	}
+	logLvl.SetLevel(zapLevel)
+
+	return nil
+}
+
+// parseLogLevel maps one of the supported log level names to the zap level.
+func parseLogLevel(level string) (zapcore.Level, error) {
 	switch level {
 	case DebugLevel:
-		logLvl.SetLevel(zapcore.DebugLevel)
+		return zapcore.DebugLevel, nil
 	case InfoLevel:
-		logLvl.SetLevel(zapcore.InfoLevel)
+		return zapcore.InfoLevel, nil
 	case WarnLevel, WarningLevel:
-		logLvl.SetLevel(zapcore.WarnLevel)
+		return zapcore.WarnLevel, nil
 	case ErrorLevel:
-		logLvl.SetLevel(zapcore.ErrorLevel)
+		return zapcore.ErrorLevel, nil
 	case FatalLevel:
-		logLvl.SetLevel(zapcore.FatalLevel)
+		return zapcore.FatalLevel, nil
 	default:
-		return errors.New(fmt.Sprintf("unknown log level %v, so log level in not set", level))
+		return zapcore.InfoLevel, errors.New(fmt.Sprintf("unknown log level %v", level))
+	}
+}
+
+// parseLevelNames maps the levels of Config.LevelNameMap to their names.
+func parseLevelNames(levelNameMap map[string]string) (map[zapcore.Level]string, error) {
+	names := make(map[zapcore.Level]string, len(levelNameMap))
+	for level, name := range levelNameMap {
+		zapLevel, err := parseLogLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		names[zapLevel] = name
+	}
+	return names, nil
+}
+
+// parseCallerEncoder provides the caller encoder of Config.CallerEncoder, nil for zap's default.
+func parseCallerEncoder(callerEncoder string) (zapcore.CallerEncoder, error) {
+	switch {
+	case callerEncoder == "":
+		return nil, nil
+	case callerEncoder == "short":
+		return zapcore.ShortCallerEncoder, nil
+	case callerEncoder == "full":
+		return zapcore.FullCallerEncoder, nil
+	case strings.HasPrefix(callerEncoder, "trimmed-"):
+		segments, err := strconv.Atoi(strings.TrimPrefix(callerEncoder, "trimmed-"))
+		if err != nil || segments < 1 {
+			return nil, errors.New(fmt.Sprintf("invalid caller encoder %v", callerEncoder))
+		}
+		return trimmedCallerEncoder(segments), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown caller encoder %v", callerEncoder))
+	}
+}
+
+// trimmedCallerEncoder encodes the caller with the last segments of its path, e.g. "pkg/file.go:42" for 2.
+func trimmedCallerEncoder(segments int) zapcore.CallerEncoder {
+	return func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+		if !caller.Defined {
+			enc.AppendString("undefined")
+			return
+		}
+		file := caller.File
+		for i, separators := len(file)-1, 0; i >= 0; i-- {
+			if file[i] == '/' {
+				if separators++; separators == segments {
+					file = file[i+1:]
+					break
+				}
+			}
+		}
+		enc.AppendString(file + ":" + strconv.Itoa(caller.Line))
 	}
+}
+
+// withLevelNames makes the level encoder emit the given names, and its own for the other levels.
+func withLevelNames(encodeLevel zapcore.LevelEncoder, names map[zapcore.Level]string) zapcore.LevelEncoder {
+	if len(names) == 0 {
+		return encodeLevel
+	}
+	return func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		if name, ok := names[level]; ok {
+			enc.AppendString(name)
+			return
+		}
+		encodeLevel(level, enc)
+	}
+}
+
+func setNilMessageLevel(level string) error {
+	nilMessageMutex.Lock()
+	defer nilMessageMutex.Unlock()
+	if level == NilMessageIgnore {
+		ignoreNilMessage = true
+		return nil
+	}
+
+	zapLevel, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	nilMessageLevel = zapLevel
+	ignoreNilMessage = false
 
 	return nil
 }
 
+// getNilMessageLevel provides the level of the log emitted for a nil log message, and whether it's ignored.
+func getNilMessageLevel() (zapcore.Level, bool) {
+	nilMessageMutex.RLock()
+	defer nilMessageMutex.RUnlock()
+	return nilMessageLevel, ignoreNilMessage
+}
+
+func setFieldAllowlist(keys ...string) {
+	allowlist := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		allowlist[key] = true
+	}
+
+	fieldAllowlistMutex.Lock()
+	defer fieldAllowlistMutex.Unlock()
+	fieldAllowlist = allowlist
+}
+
+func getFieldAllowlist() map[string]bool {
+	fieldAllowlistMutex.RLock()
+	defer fieldAllowlistMutex.RUnlock()
+	return fieldAllowlist
+}
+
+// setVerboseCorrelationIDs replaces the correlation ids whose messages are logged at every level.
+func setVerboseCorrelationIDs(ids ...string) {
+	verbose := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		verbose[id] = true
+	}
+
+	verboseMutex.Lock()
+	defer verboseMutex.Unlock()
+	verboseCorrelationIDs = verbose
+}
+
+// setVerboseMainCore replaces the core written by verboseCore, returning the previous one.
+func setVerboseMainCore(core zapcore.Core) zapcore.Core {
+	verboseMutex.Lock()
+	defer verboseMutex.Unlock()
+	previous := verboseMainCore
+	verboseMainCore = core
+	return previous
+}
+
+func getVerboseMainCore() zapcore.Core {
+	verboseMutex.RLock()
+	defer verboseMutex.RUnlock()
+	return verboseMainCore
+}
+
+func isVerboseCorrelationID(id string) bool {
+	verboseMutex.RLock()
+	defer verboseMutex.RUnlock()
+	return verboseCorrelationIDs[id]
+}
+
+func setExitFunc(fn func(code int)) {
+	if fn == nil {
+		fn = os.Exit
+	}
+
+	exitFuncMutex.Lock()
+	defer exitFuncMutex.Unlock()
+	exitFunc = fn
+}
+
+func getExitFunc() func(code int) {
+	exitFuncMutex.RLock()
+	defer exitFuncMutex.RUnlock()
+	return exitFunc
+}
+
+func setQuietErrors(errs ...error) {
+	quietErrorsMutex.Lock()
+	defer quietErrorsMutex.Unlock()
+	quietErrors = errs
+}
+
+// isQuietError reports whether err matches one of the errors logged without stack trace.
+func isQuietError(err error) bool {
+	quietErrorsMutex.RLock()
+	errs := quietErrors
+	quietErrorsMutex.RUnlock()
+	for _, quiet := range errs {
+		if errors.Is(err, quiet) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedFields are the additional properties added by the logger itself, which the allowlist never drops.
+var generatedFields = map[string]bool{truncated: true, statusText: true, duplicateKeys: true}
+
+// isFieldAllowed reports whether an additional property is emitted with the allowlist. The allowlist doesn't
+// apply in development.
+func isFieldAllowed(allowlist map[string]bool, key string) bool {
+	if len(allowlist) == 0 || isDevelopment() {
+		return true
+	}
+	return allowlist[key] || generatedFields[key]
+}
+
+func addCallerSkip(n int) {
+	GetZapLogger() // builds the first logger, which takes the lock itself
+	zapLoggerSwapMutex.Lock()
+	defer zapLoggerSwapMutex.Unlock()
+	zapLogger.Store(zapLogger.Load().(*zap.Logger).WithOptions(zap.AddCallerSkip(n)))
+	atomic.AddInt32(&callerSkip, int32(n))
+}
+
+// getCallerSkip provides the caller frames skipped with AddCallerSkip.
+func getCallerSkip() int {
+	return int(atomic.LoadInt32(&callerSkip))
+}
+
+// latencyUnitDivisor provides the number of nanoseconds in a latency unit. Empty means nanoseconds.
+func latencyUnitDivisor(unit string) (int64, error) {
+	switch unit {
+	case "", ns:
+		return 1, nil
+	case us:
+		return int64(time.Microsecond), nil
+	case ms:
+		return int64(time.Millisecond), nil
+	case sec:
+		return int64(time.Second), nil
+	default:
+		return 0, errors.New(fmt.Sprintf("unknown latency unit %v", unit))
+	}
+}
+
+// renderLatency converts the latency to the configured unit. Nanoseconds stay an integer.
+func renderLatency(nanoseconds int64) (string, interface{}) {
+	divisor, err := latencyUnitDivisor(getConfig().LatencyUnit)
+	if err != nil || divisor == 1 {
+		return ns, nanoseconds
+	}
+	return getConfig().LatencyUnit, float64(nanoseconds) / float64(divisor)
+}
+
+func registerMessageTransformer(transformer func(string) string) {
+	transformersMutex.Lock()
+	defer transformersMutex.Unlock()
+	// The slice is copied, so transformMessage can range over the previous one without holding the lock.
+	transformers = append(transformers[:len(transformers):len(transformers)], transformer)
+}
+
+// transformMessage applies the registered message transformers in registration order.
+func transformMessage(message string) string {
+	transformersMutex.RLock()
+	registered := transformers
+	transformersMutex.RUnlock()
+	for _, transformer := range registered {
+		message = transformer(message)
+	}
+	return message
+}
+
+// isDevelopment reports whether the running logger was built for the DEVELOPMENT or DEV logger environment.
+func isDevelopment() bool {
+	env, _ := logEnv.Load().(string)
+	return env == development || env == dev
+}
+
 func getLogLevel() zap.AtomicLevel {
 	return logLvl
 }
@@ -186,58 +805,364 @@ func getGlobalTags() map[string]string {
 	globalTags := make(map[string]string)
 
 	globalTags["application"] = application
+	globalTags["component"] = getComponentName()
+	if environment := getEnvironment(); environment != "" {
+		globalTags["environment"] = environment
+	}
+	versionInfoMutex.RLock()
+	for k, v := range versionInfo {
+		globalTags[k] = v
+	}
+	versionInfoMutex.RUnlock()
+	tags, _ := envTags.Load().(map[string]string)
+	for k, v := range tags {
+		globalTags[k] = v
+	}
+	return globalTags
+}
+
+// getGlobalTagFields provides the zap fields of the global tags, computed once until they may have changed,
+// including a change of the ENVIRONMENT variable.
+// The returned slice is shared: its capacity is its length, so appending to it copies it.
+func getGlobalTagFields() []zap.Field {
+	environment := getEnvironment()
+	globalTagFieldsMutex.RLock()
+	fields, cachedEnvironment := globalTagFields, globalTagEnvironment
+	globalTagFieldsMutex.RUnlock()
+	if fields != nil && cachedEnvironment == environment {
+		return fields
+	}
+
+	tags := getGlobalTags()
+	fields = make([]zap.Field, 0, len(tags))
+	for k, v := range tags {
+		fields = append(fields, zap.String(k, v))
+	}
+	fields = fields[:len(fields):len(fields)]
+
+	globalTagFieldsMutex.Lock()
+	globalTagFields, globalTagEnvironment = fields, tags["environment"]
+	globalTagFieldsMutex.Unlock()
+	return fields
+}
+
+// resetGlobalTagFields drops the cached global tag fields, after a change of the global tags.
+func resetGlobalTagFields() {
+	globalTagFieldsMutex.Lock()
+	globalTagFields = nil
+	globalTagFieldsMutex.Unlock()
+}
+
+// getEnvTags reads the global tags from the variables prefixed with EnvTagPrefix.
+func getEnvTags() map[string]string {
+	tags := make(map[string]string)
+	for _, variable := range os.Environ() {
+		name, value := variable, ""
+		if i := strings.Index(variable, "="); i >= 0 {
+			name, value = variable[:i], variable[i+1:]
+		}
+		if strings.HasPrefix(name, EnvTagPrefix) && len(name) > len(EnvTagPrefix) {
+			tags[strings.ToLower(strings.TrimPrefix(name, EnvTagPrefix))] = value
+		}
+	}
+	return tags
+}
+
+// getComponentName provides the component tag: the name set with SetComponentName, otherwise
+// COMPONENT_NAME, otherwise derived from the binary name. It's computed once.
+func getComponentName() string {
+	componentNameOnce.Do(func() {
+		name := os.Getenv(ComponentName)
+		if name == "" {
+			name = deriveComponentName()
+		}
+		componentNameMutex.Lock()
+		componentName = name
+		componentNameMutex.Unlock()
+	})
+
+	componentNameMutex.RLock()
+	defer componentNameMutex.RUnlock()
+	return componentName
+}
+
+func setComponentName(name string) {
+	componentNameOnce.Do(func() {})
+	componentNameMutex.Lock()
+	componentName = name
+	componentNameMutex.Unlock()
+	resetGlobalTagFields()
+}
+
+// getEnvironment provides the environment tag: Config.Environment, otherwise ENVIRONMENT.
+func getEnvironment() string {
+	if getConfig().Environment != "" {
+		return getConfig().Environment
+	}
+	return os.Getenv(Environment)
+}
+
+// setVersionInfo sets the version tags, leaving out the empty ones
+func setVersionInfo(version, commit, buildTime string) {
+	info := make(map[string]string)
+	for k, v := range map[string]string{"version": version, "commit": commit, "build_time": buildTime} {
+		if v != "" {
+			info[k] = v
+		}
+	}
+	versionInfoMutex.Lock()
+	versionInfo = info
+	versionInfoMutex.Unlock()
+	resetGlobalTagFields()
+}
+
+// deriveComponentName derives the component from the binary name, or from the module path for
+// binaries built by go run, which are named after the temporary build directory.
+func deriveComponentName() string {
 	tempComponent := os.Args[0] // this might provide value like "/go/bin/usersapi"
 
+	// go run builds binaries like "/tmp/go-build123/b001/exe/main"
+	if strings.Contains(tempComponent, string(os.PathSeparator)+"go-build") {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+			return info.Main.Path[strings.LastIndex(info.Main.Path, "/")+1:]
+		}
+	}
+
 	// Get just the app name and not the whole path. For example: out of "/go/bin/usersapi", just get "usersapi"
-	globalTags["component"] = tempComponent[strings.LastIndex(tempComponent, "/")+1:]
-	return globalTags
+	return tempComponent[strings.LastIndex(tempComponent, "/")+1:]
 }
 
 // zap info wrapper
 func infoMessage(logMessage *LogMessage) {
-	callZapLogger(logMessage, GetZapLogger().Info)
+	callZapLogger(logMessage, zapcore.InfoLevel)
 }
 
 // errorMessage wraps zap "Error" function
 func errorMessage(logMessage *LogMessage) {
-	callZapLogger(logMessage, GetZapLogger().Error)
+	callZapLogger(logMessage, zapcore.ErrorLevel)
 }
 
 // fatalMessage wraps zap "Fatal" function
 func fatalMessage(logMessage *LogMessage) {
-	callZapLogger(logMessage, GetZapLogger().Fatal)
+	callZapLogger(logMessage, zapcore.FatalLevel)
 }
 
 // warnMessage wraps zap "Warn" function
 func warnMessage(logMessage *LogMessage) {
-	callZapLogger(logMessage, GetZapLogger().Warn)
+	callZapLogger(logMessage, zapcore.WarnLevel)
 }
 
 // debugMessage wraps zap "Debug" function
 func debugMessage(logMessage *LogMessage) {
-	callZapLogger(logMessage, GetZapLogger().Debug)
+	callZapLogger(logMessage, zapcore.DebugLevel)
 }
 
-// callZapLogger calls the zap logger functions.
-func callZapLogger(logMessage *LogMessage, logCaller func(msg string, fields ...zap.Field)) {
+// callZapLogger logs the message with the zap logger at the given level.
+func callZapLogger(logMessage *LogMessage, level zapcore.Level) {
+	var exit bool
 	if logMessage == nil {
-		logCaller = GetZapLogger().Error
-		logCaller(nilLogMessage)
+		if level, ignore := getNilMessageLevel(); !ignore {
+			exit = writeZapEntry(GetZapLogger(), level, nilLogMessage)
+		}
 	} else {
-		if logEnv == development || logEnv == dev {
-			logCaller(fmt.Sprintf("%v %v", logMessage.Message, logMessage.SerializeFields(true)))
+		messageLogger := GetZapLogger()
+		if logMessage.zapLogger != nil {
+			messageLogger = logMessage.zapLogger
+		}
+		if logMessage.callerSkip != 0 {
+			messageLogger = messageLogger.WithOptions(zap.AddCallerSkip(logMessage.callerSkip))
+		}
+		// Entries with their own logger, e.g. withCore, aren't written to the main output.
+		if logMessage.zapLogger == nil && logMessage.CorrelationId != "" && isVerboseCorrelationID(logMessage.CorrelationId) {
+			messageLogger = withVerboseCore(messageLogger)
+		}
+		if logMessage.noStacktrace {
+			messageLogger = messageLogger.WithOptions(zap.AddStacktrace(zap.LevelEnablerFunc(func(zapcore.Level) bool { return false })))
+		}
+		logMessage = withLazyFields(logMessage, messageLogger.Core().Enabled(level))
+		logMessage = withAmbientFields(logMessage)
+		// Paths are redacted first, as registered, before the fields are capped or flattened.
+		logMessage = withRedactedPaths(logMessage)
+		logMessage = withCappedFields(logMessage)
+		logMessage = withFlattenedFields(logMessage)
+		logMessage = withFormattedBools(logMessage)
+		logMessage = withNormalizedHTTPFields(logMessage)
+		message := transformMessage(logMessage.Message)
+		if message == "" {
+			message = getConfig().EmptyMessage
+		}
+
+		// CompactJSON keeps the fields structured in development too, instead of serializing them into the message.
+		if isDevelopment() && !getConfig().CompactJSON {
+			if logMessage.indent > 0 {
+				message = strings.Repeat(indentUnit, logMessage.indent) + message
+			}
+			line := logMessage.SerializeFields(true)
+			if getConfig().DevJSONFields {
+				line = logMessage.SerializeFieldsJSON(true)
+			}
+			if message != "" {
+				line = fmt.Sprintf("%v %v", message, line)
+			}
+			exit = writeZapEntry(messageLogger, level, line, logMessage.getControlZapFields()...)
 		} else {
-			fields := logMessage.getZapFields()
-			logCaller(logMessage.Message, fields...)
+			fields := append(logMessage.getZapFields(), logMessage.getControlZapFields()...)
+			exit = writeZapEntry(messageLogger, level, message, fields...)
 		}
 	}
-	GetZapLogger().Sync()
+	if getConfig().FlushLevel == "" {
+		GetZapLogger().Sync()
+	}
+	if exit {
+		getExitFunc()(1)
+	}
+}
+
+// writeZapEntry logs through zap's Check so the level is chosen at runtime. The sequence number is
+// only taken once the entry is known to be logged, so gaps downstream mean lost logs.
+// With Config.OrderedWrites, entries are timestamped, numbered and written one at a time.
+// It reports whether a FATAL entry was written, for the caller to call exitFunc once nothing is locked.
+func writeZapEntry(logger *zap.Logger, level zapcore.Level, msg string, fields ...zap.Field) (exit bool) {
+	if getConfig().OrderedWrites {
+		orderedWritesMutex.Lock()
+		defer orderedWritesMutex.Unlock()
+	}
+	if checkedEntry := logger.Check(level, msg); checkedEntry != nil {
+		if getConfig().SequenceNumbers {
+			fields = append(fields, zap.Uint64(seq, atomic.AddUint64(&sequence, 1)))
+		}
+		if level == zapcore.FatalLevel {
+			// zap calls os.Exit after writing FATAL entries, the caller exits with exitFunc instead.
+			checkedEntry = checkedEntry.Should(checkedEntry.Entry, zapcore.WriteThenNoop)
+			exit = true
+		}
+		checkedEntry.Write(fields...)
+	}
+	return exit
+}
+
+// withCappedFields returns a copy of the log message with at most Config.MaxFields additional properties,
+// and the fields_truncated count of the dropped ones, or the message itself when it's within the limit.
+// The kept properties are picked without sorting all the keys, so which ones are dropped is arbitrary.
+func withCappedFields(logMessage *LogMessage) *LogMessage {
+	maxFields := getConfig().MaxFields
+	if maxFields <= 0 || len(logMessage.AdditionalProperties) <= maxFields {
+		return logMessage
+	}
+
+	capped := *logMessage
+	capped.AdditionalProperties = make(map[string]interface{}, maxFields+1)
+	for k, v := range logMessage.AdditionalProperties {
+		if len(capped.AdditionalProperties) == maxFields {
+			break
+		}
+		capped.AdditionalProperties[k] = v
+	}
+	capped.AdditionalProperties[truncated] = len(logMessage.AdditionalProperties) - maxFields
+	return &capped
+}
+
+// withFlattenedFields returns a copy of the log message with its nested additional properties flattened
+// when Config.FlattenFields is set, or the message itself otherwise.
+func withFlattenedFields(logMessage *LogMessage) *LogMessage {
+	if !getConfig().FlattenFields || len(logMessage.AdditionalProperties) == 0 {
+		return logMessage
+	}
+	flattened := *logMessage
+	flattened.AdditionalProperties = flattenFields(logMessage.AdditionalProperties)
+	return &flattened
+}
+
+// withFormattedBools returns a copy of the log message with its boolean additional properties rendered
+// with Config.BoolFormat, or the message itself when there's no format or no boolean.
+func withFormattedBools(logMessage *LogMessage) *LogMessage {
+	if getConfig().BoolFormat == "" {
+		return logMessage
+	}
+
+	var formatted *LogMessage
+	for k, v := range logMessage.AdditionalProperties {
+		b, ok := v.(bool)
+		if !ok {
+			continue
+		}
+		if formatted == nil {
+			copied := *logMessage
+			copied.AdditionalProperties = make(map[string]interface{}, len(logMessage.AdditionalProperties))
+			for key, value := range logMessage.AdditionalProperties {
+				copied.AdditionalProperties[key] = value
+			}
+			formatted = &copied
+		}
+		formatted.AdditionalProperties[k] = formatBool(b)
+	}
+
+	if formatted == nil {
+		return logMessage
+	}
+	return formatted
+}
+
+// loggerContextKey provides the key of the LoggerContext field, empty when it's disabled.
+func loggerContextKey() string {
+	switch getConfig().LoggerContextKey {
+	case "":
+		return loggerContext
+	case "-":
+		return ""
+	default:
+		return getConfig().LoggerContextKey
+	}
+}
+
+// getControlZapFields provides the fields that are consumed by the wrapping cores instead of being encoded.
+func (l *LogMessage) getControlZapFields() []zap.Field {
+	var fields []zap.Field
+	if !l.Timestamp.IsZero() {
+		fields = append(fields, zap.Field{Key: timestampOverride, Type: zapcore.SkipType, Interface: l.Timestamp})
+	}
+	if getConfig().Sampling != nil && getConfig().Sampling.KeyFunc != nil {
+		fields = append(fields, zap.Field{Key: samplingKey, Type: zapcore.SkipType, String: getConfig().Sampling.KeyFunc(l)})
+	}
+	return fields
 }
 
 func (l *LogMessage) getZapFields() []zap.Field {
+	fields := l.getMessageZapFields()
+	if !getConfig().Compact {
+		if len(fields) == 0 && !getConfig().FieldCount {
+			// The most common case, a message without fields, logs the cached global tag fields as is.
+			return getGlobalTagFields()
+		}
+		fields = append(fields, getGlobalTagFields()...)
+	}
+	if getConfig().FieldCount {
+		fields = append(fields, zap.Int(fieldCount, len(fields)))
+	}
+
+	return fields
+}
+
+// getMessageZapFields provides the zap fields of the log message without the global tags.
+func (l *LogMessage) getMessageZapFields() []zap.Field {
 	var fields []zap.Field
-	if l.LoggerContext != "" {
-		fields = append(fields, zap.String(loggerContext, l.LoggerContext))
+	if key := loggerContextKey(); l.LoggerContext != "" && key != "" {
+		fields = append(fields, zap.String(key, l.LoggerContext))
+	}
+	if l.CorrelationId != "" {
+		fields = append(fields, zap.String(correlationId, l.CorrelationId))
+	}
+	if l.TraceID != "" {
+		fields = append(fields, zap.String(traceID, l.TraceID))
+	}
+	if l.SpanID != "" {
+		fields = append(fields, zap.String(spanID, l.SpanID))
+	}
+	if l.RequestID != "" {
+		fields = append(fields, zap.String(requestID, l.RequestID))
+	}
+	if l.TenantID != "" {
+		fields = append(fields, zap.String(tenantID, l.TenantID))
 	}
 	if l.Status != 0 {
 		fields = append(fields, zap.Int(status, l.Status))
@@ -267,15 +1192,38 @@ func (l *LogMessage) getZapFields() []zap.Field {
 		fields = append(fields, zap.String(endTime, l.EndTime.Format(UtcTimeFormat)))
 	}
 	if l.LatencyNanoSeconds != 0 {
-		fields = append(fields, zap.String(latencyUnit, ns))
-		fields = append(fields, zap.Int64(latency, l.LatencyNanoSeconds))
+		unit, value := renderLatency(l.LatencyNanoSeconds)
+		fields = append(fields, zap.String(latencyUnit, unit))
+		fields = append(fields, zap.Any(latency, preciseInteger(value)))
 	}
+	if len(l.AdditionalProperties) == 0 {
+		return fields
+	}
+	properties := make(map[string]interface{}, len(l.AdditionalProperties))
+	allowlist := getFieldAllowlist()
 	for key, val := range l.AdditionalProperties {
-		fields = append(fields, zap.Any(key, val))
+		if isFieldAllowed(allowlist, key) {
+			properties[key] = val
+		}
 	}
-
-	for k, v := range getGlobalTags() {
-		fields = append(fields, zap.String(k, v))
+	if getConfig().NestDottedKeys && !isDevelopment() {
+		properties = nestDottedKeys(properties)
+	}
+	for _, key := range sortedKeys(properties) {
+		val := properties[key]
+		if placeholder, ok := unsupportedValuePlaceholder(val); ok {
+			fields = append(fields, zap.String(key, placeholder))
+			continue
+		}
+		if marshaler, ok := val.(zapcore.ObjectMarshaler); ok {
+			fields = append(fields, zap.Object(key, marshaler))
+			continue
+		}
+		if sorted, ok := asSortedMap(val); ok {
+			fields = append(fields, zap.Object(key, sorted))
+			continue
+		}
+		fields = append(fields, zap.Any(key, preciseInteger(val)))
 	}
 
 	return fields
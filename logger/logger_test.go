@@ -0,0 +1,775 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// testBuffer collects the logs written to a "buffer://name" output path.
+type testBuffer struct {
+	mutex   sync.Mutex
+	buffer  bytes.Buffer
+	syncs   int
+	closes  int
+	discard bool // drops the writes, for benchmarks
+}
+
+func (b *testBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.discard {
+		return len(p), nil
+	}
+	return b.buffer.Write(p)
+}
+
+func (b *testBuffer) Sync() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.syncs++
+	return nil
+}
+
+func (b *testBuffer) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closes++
+	return nil
+}
+
+func (b *testBuffer) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.buffer.Reset()
+	b.syncs = 0
+	b.closes = 0
+}
+
+func (b *testBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// syncCount returns the number of syncs since the last Reset.
+func (b *testBuffer) syncCount() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.syncs
+}
+
+// closeCount returns the number of closes since the last Reset.
+func (b *testBuffer) closeCount() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.closes
+}
+
+// lines returns the logged lines, without the trailing newlines.
+func (b *testBuffer) lines() []string {
+	output := strings.TrimSuffix(b.String(), "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// entries decodes the logged JSON lines.
+func (b *testBuffer) entries(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range b.lines() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// lastEntry decodes the last logged JSON line.
+func (b *testBuffer) lastEntry(t *testing.T) map[string]interface{} {
+	t.Helper()
+	entries := b.entries(t)
+	if len(entries) == 0 {
+		t.Fatal("nothing was logged")
+	}
+	return entries[len(entries)-1]
+}
+
+var (
+	testBuffersMutex sync.Mutex
+	testBuffers      = make(map[string]*testBuffer)
+)
+
+func init() {
+	if err := zap.RegisterSink("buffer", func(u *url.URL) (zap.Sink, error) {
+		return getTestBuffer(u.Host), nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// getTestBuffer returns the buffer of the "buffer://name" output path, created on first use.
+func getTestBuffer(name string) *testBuffer {
+	testBuffersMutex.Lock()
+	defer testBuffersMutex.Unlock()
+	if buffer, ok := testBuffers[name]; ok {
+		return buffer
+	}
+	testBuffers[name] = &testBuffer{}
+	return testBuffers[name]
+}
+
+// initTestLogger builds the logger with cfg writing to an emptied "buffer://" output, which it returns.
+// The default logger is restored when the test ends.
+func initTestLogger(t testing.TB, cfg Config) *testBuffer {
+	t.Helper()
+	output := getTestBuffer("")
+	output.Reset()
+	if err := buildZapLogger(cfg, "buffer"); err != nil {
+		t.Fatal(err)
+	}
+	initZapLoggerOnce.Do(func() {})
+
+	t.Cleanup(func() {
+		resetTestLogger(t)
+	})
+	return output
+}
+
+// initBenchmarkLogger builds the logger with cfg writing to a "buffer://" output that drops the logs.
+func initBenchmarkLogger(b *testing.B, cfg Config) {
+	b.Helper()
+	output := initTestLogger(b, cfg)
+	output.mutex.Lock()
+	output.discard = true
+	output.mutex.Unlock()
+	b.Cleanup(func() {
+		output.mutex.Lock()
+		output.discard = false
+		output.mutex.Unlock()
+	})
+}
+
+// resetTestLogger rebuilds the default logger.
+func resetTestLogger(t testing.TB) {
+	t.Helper()
+	if err := buildZapLogger(Config{}, ""); err != nil {
+		t.Error(err)
+	}
+}
+
+// setEnv sets an environment variable until the test ends, when the default logger is rebuilt without it.
+func setEnv(t *testing.T, key, value string) {
+	t.Helper()
+	previous, found := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+		resetTestLogger(t)
+	})
+}
+
+func TestSinksWithDifferentLevels(t *testing.T) {
+	infoSink, errorSink := getTestBuffer("info-sink"), getTestBuffer("error-sink")
+	infoSink.Reset()
+	errorSink.Reset()
+	initTestLogger(t, Config{Sinks: []Sink{
+		{Path: "buffer://info-sink", Level: InfoLevel},
+		{Path: "buffer://error-sink", Level: ErrorLevel},
+	}})
+
+	Debug("debug message")
+	Info("info message")
+	Error("error message")
+
+	if messages := messagesOf(infoSink.entries(t)); strings.Join(messages, ",") != "info message,error message" {
+		t.Errorf("INFO sink got %v", messages)
+	}
+	if messages := messagesOf(errorSink.entries(t)); strings.Join(messages, ",") != "error message" {
+		t.Errorf("ERROR sink got %v", messages)
+	}
+}
+
+func TestInitFailureKeepsLogger(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	if err := Init(Config{Sinks: []Sink{{Path: "buffer://x", Level: "LOUD"}}}); err == nil {
+		t.Fatal("expected an error for an unknown sink level")
+	}
+	Info("still logged")
+
+	if entry := output.lastEntry(t); entry["msg"] != "still logged" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestInitClosesOutputs(t *testing.T) {
+	sink := getTestBuffer("closed-sink")
+	sink.Reset()
+	initTestLogger(t, Config{Sinks: []Sink{{Path: "buffer://closed-sink", Level: InfoLevel}}})
+
+	err := Init(Config{Sinks: []Sink{
+		{Path: "buffer://closed-sink", Level: InfoLevel},
+		{Path: "unknown://sink", Level: InfoLevel},
+	}})
+	if err == nil {
+		t.Fatal("expected an error for an unknown sink scheme")
+	}
+	if closes := sink.closeCount(); closes != 1 {
+		t.Errorf("the failed Init closed the sink %v times, want only the one it opened", closes)
+	}
+
+	initTestLogger(t, Config{})
+	if closes := sink.closeCount(); closes != 2 {
+		t.Errorf("the sink of the previous logger was closed %v times", closes-1)
+	}
+}
+
+func TestSwapKeepsRebuiltLogger(t *testing.T) {
+	initTestLogger(t, Config{})
+	restore := swapZapLogger(func(previous *zap.Logger) *zap.Logger {
+		return zap.NewNop()
+	})
+
+	output := initTestLogger(t, Config{})
+	restore()
+	Info("after restore")
+
+	if entry := output.lastEntry(t); entry["msg"] != "after restore" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestInitWhileLogging(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			WithField("value", 1.5).Info("concurrent")
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		// Init builds the logger the same way, writing to stdout.
+		if err := buildZapLogger(Config{DevFloatFormat: "%.2f"}, "buffer"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if lines := output.lines(); len(lines) != 100 {
+		t.Errorf("got %v lines", len(lines))
+	}
+	if format := getConfig().DevFloatFormat; format != "%.2f" {
+		t.Errorf("got the DevFloatFormat %q", format)
+	}
+}
+
+// messagesOf returns the messages of the entries.
+func messagesOf(entries []map[string]interface{}) []string {
+	messages := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, entry["msg"].(string))
+	}
+	return messages
+}
+
+func TestNilMessageLevel(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer SetNilMessageLevel(ErrorLevel)
+
+	if err := SetNilMessageLevel(WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	InfoMessage(nil)
+	if entry := output.lastEntry(t); entry["level"] != "warn" || entry["msg"] != nilLogMessage {
+		t.Errorf("got %v", entry)
+	}
+
+	output.Reset()
+	if err := SetNilMessageLevel(NilMessageIgnore); err != nil {
+		t.Fatal(err)
+	}
+	InfoMessage(nil)
+	if lines := output.lines(); len(lines) != 0 {
+		t.Errorf("got %v", lines)
+	}
+}
+
+func TestFieldAllowlist(t *testing.T) {
+	defer SetFieldAllowlist()
+	SetFieldAllowlist("kept")
+
+	t.Run("production", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{"kept": 1, "dropped": 2}).Info("projected")
+
+		entry := output.lastEntry(t)
+		if _, found := entry["dropped"]; found || entry["kept"] != float64(1) {
+			t.Errorf("got %v", entry)
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		setEnv(t, LoggerEnvironment, development)
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{"kept": 1, "dropped": 2}).Info("projected")
+
+		if line := output.String(); !strings.Contains(line, "dropped=2") || !strings.Contains(line, "kept=1") {
+			t.Errorf("got %q", line)
+		}
+	})
+
+	t.Run("generated fields", func(t *testing.T) {
+		output := initTestLogger(t, Config{MaxFields: 1, NormalizeHTTPFields: true})
+
+		InfoMessage(&LogMessage{Message: "projected", Status: 404, AdditionalProperties: map[string]interface{}{
+			"kept": 1, "dropped": 2, "other": 3,
+		}})
+
+		entry := output.lastEntry(t)
+		if entry[truncated] == nil || entry[statusText] != "Not Found" {
+			t.Errorf("got %v", entry)
+		}
+	})
+}
+
+func TestLatencyUnit(t *testing.T) {
+	output := initTestLogger(t, Config{LatencyUnit: "ms"})
+
+	logMessage := New()
+	logMessage.Message = "served"
+	logMessage.LatencyNanoSeconds = 1500000
+	InfoMessage(logMessage)
+
+	if entry := output.lastEntry(t); entry[latency] != 1.5 || entry[latencyUnit] != "ms" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestCheckDisabledLevelDoesNotAllocate(t *testing.T) {
+	initTestLogger(t, Config{})
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if ce := Check(DebugLevel, "disabled"); ce != nil {
+			ce.Write(zap.String("key", "value"))
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations", allocs)
+	}
+}
+
+func BenchmarkCheckDisabledLevel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if ce := Check(DebugLevel, "disabled"); ce != nil {
+			ce.Write(zap.String("key", "value"))
+		}
+	}
+}
+
+func TestMessageTransformer(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer func() { transformers = nil }()
+
+	RegisterMessageTransformer(strings.ToUpper)
+	RegisterMessageTransformer(func(message string) string { return message + "!" })
+	Info("shout")
+
+	if entry := output.lastEntry(t); entry["msg"] != "SHOUT!" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestMessageTransformerWhileLogging(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer func() { transformers = nil }()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			Info("concurrent")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		RegisterMessageTransformer(strings.TrimSpace)
+	}
+	wg.Wait()
+
+	if lines := output.lines(); len(lines) != 100 {
+		t.Errorf("got %v lines", len(lines))
+	}
+}
+
+func TestSetComponentName(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer setComponentName(getComponentName())
+
+	SetComponentName("billing")
+	Info("tagged")
+
+	if entry := output.lastEntry(t); entry["component"] != "billing" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	output := initTestLogger(t, Config{Compact: true})
+
+	WithField("id", 1).Error("compact")
+
+	entry := output.lastEntry(t)
+	for _, key := range []string{"caller", "application", "component", "stacktrace"} {
+		if _, found := entry[key]; found {
+			t.Errorf("got %v in %v", key, entry)
+		}
+	}
+}
+
+func BenchmarkCompact(b *testing.B) {
+	for _, compact := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compact=%v", compact), func(b *testing.B) {
+			initBenchmarkLogger(b, Config{Compact: compact})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				WithField("id", i).Info("benchmark")
+			}
+		})
+	}
+}
+
+func TestSetVersionInfo(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer setVersionInfo("", "", "")
+
+	SetVersionInfo("1.4.2", "3f2a9c1", "")
+	Info("stamped")
+
+	entry := output.lastEntry(t)
+	if entry["version"] != "1.4.2" || entry["commit"] != "3f2a9c1" {
+		t.Errorf("got %v", entry)
+	}
+	if _, found := entry["build_time"]; found {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestEnvironmentTag(t *testing.T) {
+	setEnv(t, Environment, "qa")
+
+	t.Run("variable", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+		Info("tagged")
+		if entry := output.lastEntry(t); entry["environment"] != "qa" {
+			t.Errorf("got %v", entry)
+		}
+	})
+
+	t.Run("changed variable", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+		Info("tagged")
+		setEnv(t, Environment, "prod")
+		Info("tagged")
+		if entry := output.lastEntry(t); entry["environment"] != "prod" {
+			t.Errorf("got %v", entry)
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		output := initTestLogger(t, Config{Environment: "staging"})
+		Info("tagged")
+		if entry := output.lastEntry(t); entry["environment"] != "staging" {
+			t.Errorf("got %v", entry)
+		}
+	})
+}
+
+func TestSequenceNumbers(t *testing.T) {
+	output := initTestLogger(t, Config{SequenceNumbers: true})
+
+	Info("first")
+	Debug("filtered")
+	Info("second")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	first, _ := entries[0][seq].(float64)
+	if first == 0 || entries[1][seq] != first+1 {
+		t.Errorf("got %v and %v", entries[0][seq], entries[1][seq])
+	}
+}
+
+func TestVerboseCorrelationIDs(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer SetVerboseCorrelationIDs()
+
+	SetVerboseCorrelationIDs("debugged")
+	for _, correlationID := range []string{"debugged", "other"} {
+		logMessage := New()
+		logMessage.Message = "debug of " + correlationID
+		logMessage.CorrelationId = correlationID
+		DebugMessage(logMessage)
+	}
+	Debug("without correlation id")
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "debug of debugged" {
+		t.Errorf("got %v", messages)
+	}
+}
+
+func TestMaxFields(t *testing.T) {
+	output := initTestLogger(t, Config{MaxFields: 3})
+
+	fields := Fields{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}
+	WithFields(fields).Info("capped")
+
+	entry := output.lastEntry(t)
+	kept := 0
+	for key := range fields {
+		if _, found := entry[key]; found {
+			kept++
+		}
+	}
+	if kept != 3 || entry[truncated] != float64(2) {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestLevelNameMap(t *testing.T) {
+	output := initTestLogger(t, Config{LevelNameMap: map[string]string{WarnLevel: "WARNING", ErrorLevel: "SEVERE"}})
+
+	Info("kept")
+	Warn("renamed")
+	Error("renamed")
+
+	entries := output.entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("got %v", entries)
+	}
+	for i, expected := range []string{"info", "WARNING", "SEVERE"} {
+		if entries[i]["level"] != expected {
+			t.Errorf("got level %v, want %v", entries[i]["level"], expected)
+		}
+	}
+}
+
+func TestLevelNameMapUnknownLevel(t *testing.T) {
+	initTestLogger(t, Config{})
+
+	if err := Init(Config{LevelNameMap: map[string]string{"LOUD": "VERY LOUD"}}); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestBoolFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "", expected: `"enabled":true,"expired":false`},
+		{format: BoolYesNo, expected: `"enabled":"yes","expired":"no"`},
+		{format: BoolOneZero, expected: `"enabled":1,"expired":0`},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			output := initTestLogger(t, Config{BoolFormat: test.format})
+
+			WithFields(Fields{"enabled": true, "expired": false}).Info("formatted")
+
+			if line := output.String(); !strings.Contains(line, test.expected) {
+				t.Errorf("%q doesn't contain %v", line, test.expected)
+			}
+		})
+	}
+}
+
+func TestOrderedWrites(t *testing.T) {
+	output := initTestLogger(t, Config{OrderedWrites: true, SequenceNumbers: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(goroutine int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				WithField("goroutine", goroutine).Info("concurrent")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries := output.entries(t)
+	if len(entries) != 400 {
+		t.Fatalf("got %v logs", len(entries))
+	}
+	for i := 1; i < len(entries); i++ {
+		previous, current := entries[i-1], entries[i]
+		if current[seq].(float64) != previous[seq].(float64)+1 || current[timeStamp].(string) < previous[timeStamp].(string) {
+			t.Fatalf("log %v is out of order: %v after %v", i, current, previous)
+		}
+	}
+}
+
+func TestDeriveComponentName(t *testing.T) {
+	defer func(arg0 string) { os.Args[0] = arg0 }(os.Args[0])
+
+	os.Args[0] = "/go/bin/usersapi"
+	if component := deriveComponentName(); component != "usersapi" {
+		t.Errorf("got %v for an installed binary", component)
+	}
+
+	os.Args[0] = "/tmp/go-build123/b001/exe/main"
+	if component := deriveComponentName(); component != "logger" {
+		t.Errorf("got %v for a go run binary", component)
+	}
+}
+
+func TestFieldCount(t *testing.T) {
+	output := initTestLogger(t, Config{FieldCount: true})
+
+	WithFields(Fields{"user": "alice", "request": map[string]interface{}{"method": "GET", "path": "/"}}).Info("counted")
+
+	entry := output.lastEntry(t)
+	others := 0
+	for key := range entry {
+		switch key {
+		case "level", "timestamp", "caller", "msg", fieldCount:
+		default:
+			others++
+		}
+	}
+	if entry[fieldCount] != float64(others) {
+		t.Errorf("got %v for %v other fields: %v", entry[fieldCount], others, entry)
+	}
+}
+
+func TestCallerEncoder(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	segments := strings.Split(file, "/")
+	tests := []struct {
+		encoder  string
+		expected string
+	}{
+		{encoder: "", expected: "logger/logger_test.go:"},
+		{encoder: "short", expected: "logger/logger_test.go:"},
+		{encoder: "full", expected: file + ":"},
+		{encoder: "trimmed-1", expected: "logger_test.go:"},
+		{encoder: "trimmed-3", expected: strings.Join(segments[len(segments)-3:], "/") + ":"},
+	}
+	for _, test := range tests {
+		t.Run(test.encoder, func(t *testing.T) {
+			output := initTestLogger(t, Config{CallerEncoder: test.encoder})
+
+			Info("located")
+
+			if caller, _ := output.lastEntry(t)["caller"].(string); !strings.HasPrefix(caller, test.expected) {
+				t.Errorf("got %v, want %v", caller, test.expected)
+			}
+		})
+	}
+
+	if err := Init(Config{CallerEncoder: "trimmed-0"}); err == nil {
+		t.Error("expected an error for an invalid caller encoder")
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	setEnv(t, LoggerEnvironment, development)
+	output := initTestLogger(t, Config{CompactJSON: true, Encoding: "console"})
+
+	WithField("user", "alice").Info("first line\nsecond line")
+	Error("with a stack trace")
+
+	lines := output.lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %q", lines)
+	}
+	entries := output.entries(t)
+	if entries[0]["user"] != "alice" || entries[0]["M"] != "first line\nsecond line" {
+		t.Errorf("got %v", entries[0])
+	}
+	if strings.Contains(output.String(), "\x1b[") {
+		t.Errorf("got colors in %q", output.String())
+	}
+}
+
+func TestEnvTags(t *testing.T) {
+	setEnv(t, EnvTagPrefix+"REGION", "us-east")
+	setEnv(t, EnvTagPrefix, "ignored")
+	output := initTestLogger(t, Config{})
+
+	Info("tagged")
+
+	entry := output.lastEntry(t)
+	if entry["region"] != "us-east" {
+		t.Errorf("got %v", entry)
+	}
+	if _, found := entry[""]; found {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestInfoWithoutFields(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	Info("first")
+	Info("second")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	for _, entry := range entries {
+		if entry["application"] == nil || entry["component"] == nil {
+			t.Errorf("missing global tags in %v", entry)
+		}
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	b.Run("no fields", func(b *testing.B) {
+		initBenchmarkLogger(b, Config{})
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			Info("benchmark")
+		}
+	})
+
+	b.Run("one field", func(b *testing.B) {
+		initBenchmarkLogger(b, Config{})
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			WithField("id", i).Info("benchmark")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+// Package loggertest provides helpers to capture and assert on the logs of the logger package in tests.
+// They live apart from the logger package, so it doesn't depend on the testing packages. NewTestLogger,
+// NewTBLogger and ExpectNoLogsAbove were logger.NewTestLogger, logger.NewTBLogger and logger.ExpectNoLogsAbove.
+package loggertest
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/mritunjaykumar/logger/logger"
+	"github.com/mritunjaykumar/logger/logger/internal/loggerhooks"
+)
+
+// TestLogger captures logs in memory so tests can assert on individual entries and fields.
+type TestLogger struct {
+	logs    *observer.ObservedLogs
+	restore func()
+}
+
+// NewTestLogger redirects all logs to memory until Close is called. The current log level still applies.
+func NewTestLogger() *TestLogger {
+	core, logs := observer.New(logger.AtomicLevel())
+	return &TestLogger{logs: logs, restore: loggerhooks.ReplaceCore(core)}
+}
+
+// Entries returns the captured entries as maps holding the "level", "message" and "caller"
+// of each entry next to its fields.
+func (l *TestLogger) Entries() []map[string]interface{} {
+	loggedEntries := l.logs.All()
+	entries := make([]map[string]interface{}, 0, len(loggedEntries))
+	for _, loggedEntry := range loggedEntries {
+		entry := loggedEntry.ContextMap()
+		entry["level"] = loggedEntry.Level.String()
+		entry["message"] = loggedEntry.Message
+		if loggedEntry.Caller.Defined {
+			entry["caller"] = loggedEntry.Caller.TrimmedPath()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Close restores the logger that was active before NewTestLogger.
+func (l *TestLogger) Close() {
+	l.restore()
+}
+
+// NewTBLogger creates an entry that logs through tb.Log, so its output is attributed to the test,
+// only shown by go test for failed tests or with -v, and not shared with other tests. The entry type
+// isn't exported by the logger package, so the entry is returned as a logger.Logger.
+func NewTBLogger(tb testing.TB) logger.Logger {
+	return loggerhooks.WithCore(zaptest.NewLogger(tb).Core()).(logger.Logger)
+}
+
+// ExpectNoLogsAbove fails the test at cleanup if any log at or above the level was written in the meantime,
+// e.g. to catch unexpected errors in integration tests. Logs are still written to the usual outputs.
+func ExpectNoLogsAbove(tb testing.TB, level string) {
+	tb.Helper()
+	zapLevel, err := loggerhooks.ParseLevel(level)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	core, logs := observer.New(zapLevel)
+	restore := loggerhooks.TeeCore(core)
+	tb.Cleanup(func() {
+		restore()
+		for _, loggedEntry := range logs.All() {
+			tb.Errorf("unexpected %v log: %v", loggedEntry.Level.CapitalString(), loggedEntry.Message)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package loggertest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mritunjaykumar/logger/logger"
+)
+
+func TestEntries(t *testing.T) {
+	logs := NewTestLogger()
+	defer logs.Close()
+
+	logger.WithField("order_id", "o-42").Warn("payment declined")
+
+	entries := logs.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %v", entries)
+	}
+	if entries[0]["order_id"] != "o-42" || entries[0]["level"] != "warn" || entries[0]["message"] != "payment declined" {
+		t.Errorf("got %v", entries[0])
+	}
+}
+
+// recordingTB records the logs, errors and cleanups of a test instead of running them.
+type recordingTB struct {
+	testing.TB
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (tb *recordingTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+// cleanup runs the cleanups in reverse order, like the testing package.
+func (tb *recordingTB) cleanup() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+func TestNewTBLogger(t *testing.T) {
+	tb := &recordingTB{TB: t}
+
+	NewTBLogger(tb).Infof("order %v attributed to the test", "o-42")
+
+	if len(tb.logs) != 1 || !strings.Contains(tb.logs[0], "order o-42 attributed to the test") {
+		t.Errorf("got %q", tb.logs)
+	}
+}
+
+// logThroughHelper logs like an application helper wrapping the logger, skipped with AddCallerSkip.
+func logThroughHelper(l logger.Logger, msg string) {
+	l.Info(msg)
+}
+
+func TestNewTBLoggerCallerSkip(t *testing.T) {
+	logger.AddCallerSkip(1)
+	defer logger.AddCallerSkip(-1)
+	tb := &recordingTB{TB: t}
+
+	_, file, line, _ := runtime.Caller(0)
+	logThroughHelper(NewTBLogger(tb), "through the helper")
+
+	caller := fmt.Sprintf("%v:%v", filepath.Base(file), line+1)
+	if len(tb.logs) != 1 || !strings.Contains(tb.logs[0], caller) {
+		t.Errorf("got %q, want caller %v", tb.logs, caller)
+	}
+}
+
+func TestExpectNoLogsAbove(t *testing.T) {
+	t.Run("pass", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		ExpectNoLogsAbove(tb, logger.WarnLevel)
+
+		logger.Info("expected")
+		tb.cleanup()
+
+		if len(tb.errors) != 0 {
+			t.Errorf("got %q", tb.errors)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		logs := NewTestLogger() // keeps the errors out of the test output
+		defer logs.Close()
+		tb := &recordingTB{TB: t}
+		ExpectNoLogsAbove(tb, logger.WarnLevel)
+
+		logger.Error("unexpected failure")
+		tb.cleanup()
+		logger.Error("after the test")
+
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "unexpected failure") {
+			t.Errorf("got %q", tb.errors)
+		}
+	})
+}
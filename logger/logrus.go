@@ -1,11 +1,34 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type Fields map[string]interface{}
 
+const (
+	// DuplicateKeysOverwrite, DuplicateKeysError and DuplicateKeysAppend are the supported
+	// Config.DuplicateKeys values.
+	DuplicateKeysOverwrite = "overwrite"
+	DuplicateKeysError     = "error"
+	DuplicateKeysAppend    = "append"
+
+	duplicateKeys = "duplicate_keys"
+)
+
+// duplicateValues are the values of a key set several times with the DuplicateKeysAppend policy.
+type duplicateValues []interface{}
+
 func (fields Fields) CloneWith(name string, value interface{}) Fields {
 	newFields := Fields{}
 
@@ -42,98 +65,366 @@ func (fields Fields) ToMap() map[string]interface{} {
 	return fieldsAsMap
 }
 
+// Logger is implemented by the entries returned from WithField, WithFields, WithError and WithContext,
+// so they can be stored in struct fields or returned from functions.
+type Logger interface {
+	Info(msg string)
+	Infof(format string, args ...interface{})
+	Debug(msg string)
+	Debugf(format string, args ...interface{})
+	Error(msg string)
+	Errorf(format string, args ...interface{})
+	Warn(msg string)
+	Warnf(format string, args ...interface{})
+	Warning(msg string)
+	Warningf(format string, args ...interface{})
+	Print(msg string)
+	Printf(format string, args ...interface{})
+	Fatal(msg string)
+	Fatalf(format string, args ...interface{})
+}
+
+var _ Logger = (*entry)(nil)
+
 type entry struct {
-	value Fields
+	value      Fields
+	timestamp  time.Time
+	ctx        context.Context
+	callerSkip int
+	zapLogger  *zap.Logger // logs through this logger instead of the package one when set
+	indent     int
+
+	lazy map[string]func() interface{} // fields computed when the log is emitted, see WithLazy
+
+	noStacktrace bool // set by WithError for quiet errors, see SetQuietErrors
+	minLevel     zapcore.Level
+	hasMinLevel  bool // logs below minLevel are dropped, see WithMinLevel
+
+	// built-in fields of the LogMessage, see WithRequest, WithCorrelationID and WithTraceID
+	correlationID string
+	traceID       string
+	spanID        string
+	requestID     string
+	tenantID      string
+	method        string
+	path          string
+	status        int
 }
 
 func (e *entry) Info(msg string) {
+	if e.skip(zapcore.InfoLevel) {
+		return
+	}
 	infoMessage(e.storeFields(msg))
 }
 
 func (e *entry) Infof(format string, args ...interface{}) {
+	if e.skip(zapcore.InfoLevel) {
+		return
+	}
 	infoMessage(e.storeFields(fmt.Sprintf(format, args...)))
 }
 
-func (e *entry) Debug(msg string) {
-	debugMessage(e.storeFields(msg))
-}
-
-func (e *entry) Debugf(format string, args ...interface{}) {
-	debugMessage(e.storeFields(fmt.Sprintf(format, args...)))
-}
-
 func (e *entry) Error(msg string) {
+	if e.skip(zapcore.ErrorLevel) {
+		return
+	}
 	errorMessage(e.storeFields(msg))
 }
 
 func (e *entry) Errorf(format string, args ...interface{}) {
+	if e.skip(zapcore.ErrorLevel) {
+		return
+	}
 	errorMessage(e.storeFields(fmt.Sprintf(format, args...)))
 }
 
 func (e *entry) Warn(msg string) {
+	if e.skip(zapcore.WarnLevel) {
+		return
+	}
 	warnMessage(e.storeFields(msg))
 }
 
 func (e *entry) Warnf(format string, args ...interface{}) {
+	if e.skip(zapcore.WarnLevel) {
+		return
+	}
 	warnMessage(e.storeFields(fmt.Sprintf(format, args...)))
 }
 
 func (e *entry) Warning(msg string) {
+	if e.skip(zapcore.WarnLevel) {
+		return
+	}
 	warnMessage(e.storeFields(msg))
 }
 
 func (e *entry) Warningf(format string, args ...interface{}) {
+	if e.skip(zapcore.WarnLevel) {
+		return
+	}
 	warnMessage(e.storeFields(fmt.Sprintf(format, args...)))
 }
 
 func (e *entry) Print(msg string) {
+	if e.skip(zapcore.InfoLevel) {
+		return
+	}
 	infoMessage(e.storeFields(msg))
 }
 
 func (e *entry) Printf(format string, args ...interface{}) {
+	if e.skip(zapcore.InfoLevel) {
+		return
+	}
 	infoMessage(e.storeFields(fmt.Sprintf(format, args...)))
 }
 
 func (e *entry) Fatal(msg string) {
+	if e.skip(zapcore.FatalLevel) {
+		return
+	}
 	fatalMessage(e.storeFields(msg))
 }
 
 func (e *entry) Fatalf(format string, args ...interface{}) {
+	if e.skip(zapcore.FatalLevel) {
+		return
+	}
 	fatalMessage(e.storeFields(fmt.Sprintf(format, args...)))
 }
 
 func (e *entry) WithField(key string, value interface{}) *entry {
-	e.value[key] = value
+	e.setField(key, value)
+	return e
+}
+
+// WithFieldAtLevel adds the field only when the logger level is at or below the given level, e.g. a heavy
+// request dump that is only wanted while debugging. Unknown levels drop the field.
+func (e *entry) WithFieldAtLevel(level string, key string, value interface{}) *entry {
+	zapLevel, err := parseLogLevel(level)
+	if err == nil && getLogLevel().Enabled(zapLevel) {
+		e.setField(key, value)
+	}
 	return e
 }
 
 func (e *entry) WithFields(fields Fields) *entry {
 	for k, v := range fields {
-		e.value[k] = v
+		e.setField(k, v)
 	}
 
 	return e
 }
 
+// setField sets a field following the Config.DuplicateKeys policy when it's already set:
+// the value is replaced, or the first one is kept and the key listed in duplicate_keys, or both
+// values are logged as an array.
+func (e *entry) setField(key string, value interface{}) {
+	existing, found := e.value[key]
+	if !found {
+		e.value[key] = value
+		return
+	}
+
+	switch getConfig().DuplicateKeys {
+	case DuplicateKeysError:
+		keys, _ := e.value[duplicateKeys].([]string)
+		e.value[duplicateKeys] = append(keys, key)
+	case DuplicateKeysAppend:
+		values, ok := existing.(duplicateValues)
+		if !ok {
+			values = duplicateValues{existing}
+		}
+		e.value[key] = append(values, value)
+	default:
+		e.value[key] = value
+	}
+}
+
+// WithError adds the error message as the "error" field. When the error, or an error it wraps,
+// has a Code() string or Code() int method, the code is added as the "error_code" field.
 func (e *entry) WithError(err error) *entry {
-	const errorFieldKey = "error"
+	const (
+		errorFieldKey     = "error"
+		errorCodeFieldKey = "error_code"
+	)
 
 	if err != nil {
 		e.value[errorFieldKey] = err.Error()
+		if code, ok := errorCode(err); ok {
+			e.value[errorCodeFieldKey] = code
+		}
+		e.noStacktrace = isQuietError(err)
+	}
+
+	return e
+}
+
+// WithErrors adds the messages of all non-nil errors as the "errors" array field,
+// e.g. to log aggregated validation failures once.
+func (e *entry) WithErrors(errs ...error) *entry {
+	const errorsFieldKey = "errors"
+
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) > 0 {
+		e.value[errorsFieldKey] = messages
+	}
+
+	return e
+}
+
+// errorCode extracts the code of a domain error implementing Code() string or Code() int.
+func errorCode(err error) (interface{}, bool) {
+	var stringCoder interface{ Code() string }
+	if errors.As(err, &stringCoder) {
+		return stringCoder.Code(), true
+	}
+
+	var intCoder interface{ Code() int }
+	if errors.As(err, &intCoder) {
+		return intCoder.Code(), true
+	}
+
+	return nil, false
+}
+
+// WithBytes adds binary data rendered as hex, or base64 depending on Config.BytesEncoding,
+// the same way in development and JSON logs.
+func (e *entry) WithBytes(key string, b []byte) *entry {
+	e.value[key] = encodeBytes(b)
+	return e
+}
+
+// WithObject adds an object encoded with its MarshalLogObject method, without reflection, in JSON logs.
+// Development logs render it as compact JSON.
+func (e *entry) WithObject(key string, obj zapcore.ObjectMarshaler) *entry {
+	if isDevelopment() {
+		e.value[key] = stringifyObject(obj)
+		return e
+	}
+	e.value[key] = obj
+	return e
+}
+
+// WithNumber adds a numeric string as an integer, or a float, so it can be aggregated.
+// The string is kept as is when it's not a number.
+func (e *entry) WithNumber(key string, v string) *entry {
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		e.value[key] = i
+	} else if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		e.value[key] = f
+	} else {
+		e.value[key] = v
+	}
+	return e
+}
+
+// WithAttempt adds the attempt and max_attempts fields of a retried operation.
+func (e *entry) WithAttempt(current, max int) *entry {
+	e.value["attempt"] = current
+	e.value["max_attempts"] = max
+	return e
+}
+
+// WithRequest sets the built-in method, path and status fields of LogMessage, so access logs written
+// with the entry API look the same as the ones written with LogMessage.
+func (e *entry) WithRequest(method, path string, status int) *entry {
+	e.method = method
+	e.path = path
+	e.status = status
+	return e
+}
+
+// WithCorrelationID sets the built-in correlation id field of LogMessage.
+func (e *entry) WithCorrelationID(id string) *entry {
+	e.correlationID = id
+	return e
+}
+
+// WithTraceID sets the built-in trace id field of LogMessage.
+func (e *entry) WithTraceID(id string) *entry {
+	e.traceID = id
+	return e
+}
+
+// WithSpanID sets the built-in span id field of LogMessage.
+func (e *entry) WithSpanID(id string) *entry {
+	e.spanID = id
+	return e
+}
+
+// WithRequestID sets the built-in request id field of LogMessage.
+func (e *entry) WithRequestID(id string) *entry {
+	e.requestID = id
+	return e
+}
+
+// WithTenantID sets the built-in tenant id field of LogMessage.
+func (e *entry) WithTenantID(id string) *entry {
+	e.tenantID = id
+	return e
+}
+
+// WithMinLevel drops the logs of the entry below the level, even when the global level is lower,
+// e.g. to only keep the warnings of a noisy component. An unknown level is ignored.
+func (e *entry) WithMinLevel(level string) *entry {
+	if zapLevel, err := parseLogLevel(level); err == nil {
+		e.minLevel = zapLevel
+		e.hasMinLevel = true
 	}
+	return e
+}
+
+// Indent prefixes development log messages with n levels of indentation, to follow nested operations
+// in local output. It has no effect outside development.
+func (e *entry) Indent(n int) *entry {
+	e.indent = n
+	return e
+}
+
+// WithTimestamp logs the entry with the given time instead of now, e.g. when replaying historical events.
+func (e *entry) WithTimestamp(timestamp time.Time) *entry {
+	e.timestamp = timestamp
+	return e
+}
 
+// WithCallerSkip skips n more caller frames, so helpers wrapping the entry report their own caller.
+func (e *entry) WithCallerSkip(n int) *entry {
+	e.callerSkip += n
 	return e
 }
 
 func (e *entry) storeFields(msg string) *LogMessage {
 	logMessage := &LogMessage{
 		Message:              msg,
+		CorrelationId:        e.correlationID,
+		TraceID:              e.traceID,
+		SpanID:               e.spanID,
+		RequestID:            e.requestID,
+		TenantID:             e.tenantID,
+		Method:               e.method,
+		Path:                 e.path,
+		Status:               e.status,
+		Timestamp:            e.timestamp,
+		callerSkip:           e.callerSkip,
+		zapLogger:            e.zapLogger,
+		indent:               e.indent,
+		noStacktrace:         e.noStacktrace,
+		lazyFields:           e.lazy,
 		AdditionalProperties: make(map[string]interface{}),
 	}
 
 	for key, val := range e.value {
 		logMessage.AdditionalProperties[key] = val
 	}
+	e.addContextFields(logMessage)
 
 	return logMessage
 }
@@ -158,6 +449,11 @@ func WithFields(fields Fields) *entry {
 	return newEntry
 }
 
+// NewLogger creates a Logger that carries the given fields on every log.
+func NewLogger(fields Fields) Logger {
+	return WithFields(fields)
+}
+
 func WithError(err error) *entry {
 	newEntry := &entry{
 		value: make(Fields),
@@ -166,6 +462,33 @@ func WithError(err error) *entry {
 	return newEntry.WithError(err)
 }
 
+func WithErrors(errs ...error) *entry {
+	newEntry := &entry{
+		value: make(Fields),
+	}
+
+	return newEntry.WithErrors(errs...)
+}
+
+// LogOnError logs msg at ERROR level with err attached, when err isn't nil. It returns err, so
+// the error can still be handled or returned:
+//
+//	return logger.LogOnError(f(), "f failed")
+func LogOnError(err error, msg string) error {
+	if err != nil {
+		errorMessage(WithError(err).storeFields(msg))
+	}
+	return err
+}
+
+// LogfOnError is LogOnError with a formatted message.
+func LogfOnError(err error, format string, args ...interface{}) error {
+	if err != nil {
+		errorMessage(WithError(err).storeFields(fmt.Sprintf(format, args...)))
+	}
+	return err
+}
+
 func Info(args ...interface{}) {
 	infoMessage(&LogMessage{Message: fmt.Sprint(args...)})
 }
@@ -174,6 +497,10 @@ func Infof(format string, args ...interface{}) {
 	infoMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
 }
 
+func Infoln(args ...interface{}) {
+	infoMessage(&LogMessage{Message: sprintln(args...)})
+}
+
 func Print(args ...interface{}) {
 	infoMessage(&LogMessage{Message: fmt.Sprint(args...)})
 }
@@ -182,6 +509,10 @@ func Printf(format string, args ...interface{}) {
 	infoMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
 }
 
+func Println(args ...interface{}) {
+	infoMessage(&LogMessage{Message: sprintln(args...)})
+}
+
 func Warn(args ...interface{}) {
 	warnMessage(&LogMessage{Message: fmt.Sprint(args...)})
 }
@@ -198,6 +529,14 @@ func Warningf(format string, args ...interface{}) {
 	warnMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
 }
 
+func Warnln(args ...interface{}) {
+	warnMessage(&LogMessage{Message: sprintln(args...)})
+}
+
+func Warningln(args ...interface{}) {
+	warnMessage(&LogMessage{Message: sprintln(args...)})
+}
+
 func Error(args ...interface{}) {
 	errorMessage(&LogMessage{Message: fmt.Sprint(args...)})
 }
@@ -206,6 +545,10 @@ func Errorf(format string, args ...interface{}) {
 	errorMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
 }
 
+func Errorln(args ...interface{}) {
+	errorMessage(&LogMessage{Message: sprintln(args...)})
+}
+
 func Fatal(args ...interface{}) {
 	fatalMessage(&LogMessage{Message: fmt.Sprint(args...)})
 }
@@ -214,22 +557,103 @@ func Fatalf(format string, args ...interface{}) {
 	fatalMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
 }
 
-func Debug(args ...interface{}) {
-	debugMessage(&LogMessage{Message: fmt.Sprint(args...)})
+func Fatalln(args ...interface{}) {
+	fatalMessage(&LogMessage{Message: sprintln(args...)})
 }
 
-func Debugf(format string, args ...interface{}) {
-	debugMessage(&LogMessage{Message: fmt.Sprintf(format, args...)})
+// sprintln formats like fmt.Sprintln, without the trailing newline.
+func sprintln(args ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
 }
 
 func SetLevel(level string) error {
 	return setLogLevel(level)
 }
 
+// RefreshLevelFromEnv re-reads LOG_LEVEL and applies it to the running logger without rebuilding it.
+func RefreshLevelFromEnv() error {
+	return setLogLevel(os.Getenv(LogLevel))
+}
+
 func GetLevel() string {
 	return getLogLevel().String()
 }
 
+// IsDevelopment reports whether the logger was built for the DEVELOPMENT or DEV logger environment,
+// e.g. for packages rendering their values more concisely in development logs.
+func IsDevelopment() bool {
+	GetZapLogger()
+	return isDevelopment()
+}
+
+// AtomicLevel returns the dynamic level of the logger, e.g. to serve it with zap's HTTP handler or to share it
+// with other zap loggers. The level is the same for the whole process, Init only changes its value.
+func AtomicLevel() zap.AtomicLevel {
+	GetZapLogger()
+	return getLogLevel()
+}
+
+// SetNilMessageLevel sets the level used to report a nil *LogMessage, ERROR by default.
+// Use NilMessageIgnore to ignore nil messages silently.
+func SetNilMessageLevel(level string) error {
+	return setNilMessageLevel(level)
+}
+
+// SetFieldAllowlist restricts the additional fields emitted outside development to the given keys.
+// Built-in fields, global tags and the fields added by the logger itself, such as fields_truncated, are
+// always emitted. Calling it without keys removes the restriction.
+func SetFieldAllowlist(keys ...string) {
+	setFieldAllowlist(keys...)
+}
+
+// SetVerboseCorrelationIDs logs the messages with one of the correlation ids at every level, including
+// DEBUG, whatever the configured level, to debug specific requests. Calling it without ids stops it.
+func SetVerboseCorrelationIDs(ids ...string) {
+	setVerboseCorrelationIDs(ids...)
+}
+
+// SetQuietErrors makes entry.WithError skip the stack trace for the errors matching one of errs
+// with errors.Is, such as expected context.Canceled or io.EOF errors. It replaces the previous ones.
+func SetQuietErrors(errs ...error) {
+	setQuietErrors(errs...)
+}
+
+// SetExitFunc replaces os.Exit as the function called with 1 after FATAL logs, for libraries to
+// trigger a graceful shutdown or a panic instead of exiting the host application. nil restores os.Exit.
+func SetExitFunc(fn func(code int)) {
+	setExitFunc(fn)
+}
+
+// AddCallerSkip skips n more caller frames for every log, for applications that wrap this package
+// in their own helpers. It adds to the previous calls.
+func AddCallerSkip(n int) {
+	addCallerSkip(n)
+}
+
+// RegisterMessageTransformer adds a function applied to every message before it's logged, e.g. to scrub PII.
+// Transformers run in registration order.
+func RegisterMessageTransformer(transformer func(string) string) {
+	registerMessageTransformer(transformer)
+}
+
+// SetComponentName overrides the component tag, which is otherwise taken from COMPONENT_NAME
+// or derived from the binary name.
+func SetComponentName(name string) {
+	setComponentName(name)
+}
+
+// SetVersionInfo stamps every log with version, commit and build_time tags, typically set from
+// variables injected with -ldflags. Empty values are left out.
+func SetVersionInfo(version, commit, buildTime string) {
+	setVersionInfo(version, commit, buildTime)
+}
+
+// WatchSignalForLevel flushes the logger and re-reads LOG_LEVEL whenever sig is received,
+// so verbosity can be changed without a restart. Calling stop stops watching the signal.
+func WatchSignalForLevel(sig os.Signal) (stop func()) {
+	return watchSignalForLevel(sig)
+}
+
 // AddStacktrace configures the Logger to record a stack trace for all messages at or above a given level.
 func AddStackTrace(logLevel string) {
 	addStackTrace(logLevel)
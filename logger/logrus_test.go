@@ -0,0 +1,358 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestPrintlnFunctions(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	args := []interface{}{"retrying", 3, "times", 1.5, true}
+	want := strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+
+	for name, println := range map[string]func(...interface{}){
+		"Infoln":  Infoln,
+		"Println": Println,
+		"Warnln":  Warnln,
+		"Errorln": Errorln,
+	} {
+		output.Reset()
+		println(args...)
+		if entry := output.lastEntry(t); entry["msg"] != want {
+			t.Errorf("%v logged %q, want %q", name, entry["msg"], want)
+		}
+	}
+}
+
+func TestWithFieldsAsLogger(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	var requestLogger Logger = WithFields(Fields{"request_id": "r-1"})
+	requestLogger.Info("through the interface")
+
+	if entry := output.lastEntry(t); entry["request_id"] != "r-1" || entry["msg"] != "through the interface" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+// logThroughHelper is a wrapper whose own caller must be reported.
+func logThroughHelper(message string) {
+	WithField("wrapped", true).WithCallerSkip(1).Info(message)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	_, _, line, _ := runtime.Caller(0)
+	logThroughHelper("wrapped")
+
+	if entry := output.lastEntry(t); entry["caller"] != fmt.Sprintf("logger/logrus_test.go:%v", line+1) {
+		t.Errorf("got %v", entry["caller"])
+	}
+}
+
+func TestRefreshLevelFromEnv(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	setEnv(t, LogLevel, ErrorLevel)
+
+	if err := RefreshLevelFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	Info("dropped")
+	Error("kept")
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "kept" {
+		t.Errorf("got %v", messages)
+	}
+}
+
+// codedError is a domain error exposing its code.
+type codedError struct {
+	code string
+}
+
+func (e codedError) Error() string {
+	return "coded failure"
+}
+
+func (e codedError) Code() string {
+	return e.code
+}
+
+func TestWithErrorCode(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	WithError(fmt.Errorf("charging: %w", codedError{code: "CARD_DECLINED"})).Error("failed")
+
+	if entry := output.lastEntry(t); entry["error_code"] != "CARD_DECLINED" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestWithErrors(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	WithErrors(errors.New("name is required"), nil, errors.New("age is negative")).Warn("invalid user")
+
+	entry := output.lastEntry(t)
+	if errs, _ := entry["errors"].([]interface{}); len(errs) != 2 || errs[0] != "name is required" || errs[1] != "age is negative" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestAtomicLevel(t *testing.T) {
+	level := AtomicLevel()
+	output := initTestLogger(t, Config{})
+
+	level.SetLevel(zapcore.WarnLevel)
+	Info("filtered")
+	level.SetLevel(zapcore.InfoLevel)
+	Info("logged")
+	level.SetLevel(zapcore.ErrorLevel)
+	Warn("filtered")
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "logged" {
+		t.Errorf("got %v", messages)
+	}
+}
+
+func TestWithFieldAtLevel(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	WithFields(Fields{}).WithFieldAtLevel(DebugLevel, "dump", "heavy").Info("at INFO level")
+	AtomicLevel().SetLevel(zapcore.DebugLevel)
+	WithFields(Fields{}).WithFieldAtLevel(DebugLevel, "dump", "heavy").Info("at DEBUG level")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	if _, found := entries[0]["dump"]; found {
+		t.Errorf("got %v", entries[0])
+	}
+	if entries[1]["dump"] != "heavy" {
+		t.Errorf("got %v", entries[1])
+	}
+}
+
+func TestIndent(t *testing.T) {
+	t.Run("development", func(t *testing.T) {
+		setEnv(t, LoggerEnvironment, development)
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{}).Indent(2).Info("nested step")
+
+		if line := output.String(); !strings.Contains(line, "\t    nested step") {
+			t.Errorf("got %q", line)
+		}
+	})
+
+	t.Run("production", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{}).Indent(2).Info("nested step")
+
+		if entry := output.lastEntry(t); entry["msg"] != "nested step" {
+			t.Errorf("got %v", entry)
+		}
+	})
+}
+
+func TestWithNumber(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "42", expected: `"n":42`},
+		{value: "-1.5", expected: `"n":-1.5`},
+		{value: "NaN", expected: `"n":"NaN"`},
+		{value: "1e999", expected: `"n":"1e999"`},
+		{value: "12abc", expected: `"n":"12abc"`},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			output := initTestLogger(t, Config{})
+
+			WithFields(Fields{}).WithNumber("n", test.value).Info("coerced")
+
+			if line := output.String(); !strings.Contains(line, test.expected) {
+				t.Errorf("%q doesn't contain %v", line, test.expected)
+			}
+		})
+	}
+}
+
+func TestWithAttempt(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	WithFields(Fields{}).WithAttempt(2, 5).Warn("retrying")
+
+	if entry := output.lastEntry(t); entry["attempt"] != float64(2) || entry["max_attempts"] != float64(5) {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestQuietErrors(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer SetQuietErrors()
+
+	SetQuietErrors(context.Canceled)
+	WithError(fmt.Errorf("fetching: %w", context.Canceled)).Error("quiet")
+	WithError(errors.New("unexpected")).Error("loud")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	if _, found := entries[0]["stacktrace"]; found {
+		t.Errorf("got a stack trace for a quiet error: %v", entries[0])
+	}
+	if _, found := entries[1]["stacktrace"]; !found {
+		t.Errorf("got no stack trace for another error: %v", entries[1])
+	}
+}
+
+func TestLogOnError(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	errNotFound := errors.New("not found")
+
+	if err := LogOnError(nil, "not logged"); err != nil {
+		t.Errorf("got %v", err)
+	}
+	if err := LogfOnError(errNotFound, "loading order %v", 42); err != errNotFound {
+		t.Errorf("got %v", err)
+	}
+
+	entries := output.entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %v", entries)
+	}
+	if entry := entries[0]; entry["msg"] != "loading order 42" || entry["error"] != "not found" || entry["level"] != "error" {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestWithRequest(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	WithField("user", "alice").WithRequest("POST", "/orders", 201).WithCorrelationID("abc-123").Info("served")
+	logMessage := New()
+	logMessage.Message = "served"
+	logMessage.Method = "POST"
+	logMessage.Path = "/orders"
+	logMessage.Status = 201
+	logMessage.CorrelationId = "abc-123"
+	logMessage.AdditionalProperties["user"] = "alice"
+	InfoMessage(logMessage)
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	for _, key := range []string{method, path, status, correlationId, "user"} {
+		if entries[0][key] == nil || entries[0][key] != entries[1][key] {
+			t.Errorf("got %v for %v with the entry API, %v with LogMessage", entries[0][key], key, entries[1][key])
+		}
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{policy: "", expected: `"user":"bob"`},
+		{policy: DuplicateKeysOverwrite, expected: `"user":"bob"`},
+		{policy: DuplicateKeysError, expected: `"duplicate_keys":["user"],"user":"alice"`},
+		{policy: DuplicateKeysAppend, expected: `"user":["alice","bob"]`},
+	}
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			output := initTestLogger(t, Config{DuplicateKeys: test.policy})
+
+			WithField("user", "alice").WithFields(Fields{"user": "bob"}).Info("duplicated")
+
+			if line := output.String(); !strings.Contains(line, test.expected) {
+				t.Errorf("%q doesn't contain %v", line, test.expected)
+			}
+		})
+	}
+}
+
+func TestCorrelationDimensions(t *testing.T) {
+	t.Run("production", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{}).WithTraceID("t-1").WithSpanID("s-2").WithRequestID("r-3").WithTenantID("acme").Info("correlated")
+
+		entry := output.lastEntry(t)
+		if entry[traceID] != "t-1" || entry[spanID] != "s-2" || entry[requestID] != "r-3" || entry[tenantID] != "acme" {
+			t.Errorf("got %v", entry)
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		setEnv(t, LoggerEnvironment, development)
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{}).WithTraceID("t-1").WithTenantID("acme").Info("correlated")
+
+		if line := output.String(); !strings.Contains(line, `trace-id="t-1"`) || !strings.Contains(line, `tenant-id="acme"`) {
+			t.Errorf("got %q", line)
+		}
+	})
+}
+
+func TestWithMinLevel(t *testing.T) {
+	setEnv(t, LogLevel, DebugLevel)
+	output := initTestLogger(t, Config{})
+
+	noisy := WithField("component", "poller").WithMinLevel(WarnLevel)
+	noisy.Debug("dropped")
+	noisy.Info("dropped")
+	noisy.Warn("kept")
+	Info("unaffected")
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "kept,unaffected" {
+		t.Errorf("got %v", messages)
+	}
+}
+
+func TestSetExitFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		log  func()
+	}{
+		{name: "Fatal", log: func() { Fatal("fatal") }},
+		{name: "ordered writes", cfg: Config{OrderedWrites: true}, log: func() { WithField("id", 1).Fatal("fatal") }},
+		{name: "Check", log: func() {
+			if ce := Check(FatalLevel, "fatal"); ce != nil {
+				ce.Write()
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := initTestLogger(t, test.cfg)
+			defer SetExitFunc(nil)
+			var codes []int
+			SetExitFunc(func(code int) { codes = append(codes, code) })
+
+			test.log()
+
+			if len(codes) != 1 || codes[0] != 1 {
+				t.Errorf("exit func called with %v", codes)
+			}
+			if entry := output.lastEntry(t); entry["level"] != "fatal" {
+				t.Errorf("got %v", entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logLokiURL          = "LOG_LOKI_URL"
+	lokiBatchSize       = 100 // lines pushed together, earlier when lokiFlushInterval elapses
+	lokiFlushInterval   = time.Second
+	lokiMaxPending      = 10000 // lines kept while Loki is unreachable, the oldest are dropped first
+	lokiRequestTimeout  = 5 * time.Second
+	lokiMinBackoff      = 100 * time.Millisecond
+	lokiMaxBackoff      = 30 * time.Second
+	lokiPushContentType = "application/json"
+)
+
+// lokiLine is a log line with the time it was written, in nanoseconds as Loki expects.
+type lokiLine struct {
+	timestamp string
+	line      string
+}
+
+// lokiSink pushes log lines to the Loki push API, e.g. "http://loki:3100/loki/api/v1/push", in batches,
+// labelled with the global tags. Write only buffers the lines, a background goroutine pushes them, so
+// logging never waits for Loki. Lines are buffered while Loki is unreachable, retrying with exponential
+// backoff.
+type lokiSink struct {
+	sync.Mutex
+	url     string
+	client  *http.Client
+	pending []lokiLine
+	wake    chan struct{} // signals the pushing goroutine that lines are pending
+}
+
+// lokiSinks keeps one sink per URL, so rebuilding the logger doesn't start another pushing goroutine.
+var lokiSinks sync.Map
+
+func getLokiSink(url string) *lokiSink {
+	if sink, ok := lokiSinks.Load(url); ok {
+		return sink.(*lokiSink)
+	}
+	sink, loaded := lokiSinks.LoadOrStore(url, &lokiSink{
+		url:    url,
+		client: &http.Client{Timeout: lokiRequestTimeout},
+		wake:   make(chan struct{}, 1),
+	})
+	if !loaded {
+		go sink.(*lokiSink).run()
+	}
+	return sink.(*lokiSink)
+}
+
+func (s *lokiSink) Write(p []byte) (int, error) {
+	line := lokiLine{
+		timestamp: strconv.FormatInt(time.Now().UnixNano(), 10),
+		line:      strings.TrimSuffix(string(p), "\n"),
+	}
+
+	s.Lock()
+	s.pending = append(s.pending, line)
+	if len(s.pending) > lokiMaxPending {
+		s.pending = s.pending[len(s.pending)-lokiMaxPending:]
+	}
+	full := len(s.pending) >= lokiBatchSize
+	s.Unlock()
+	if full {
+		s.signal()
+	}
+
+	// Lines that can't be pushed yet stay buffered, so the write itself never fails.
+	return len(p), nil
+}
+
+// signal wakes the pushing goroutine without blocking.
+func (s *lokiSink) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run pushes the buffered lines every lokiFlushInterval and whenever it's woken up,
+// backing off exponentially while the pushes fail.
+func (s *lokiSink) run() {
+	ticker := time.NewTicker(lokiFlushInterval)
+	defer ticker.Stop()
+
+	var backoff time.Duration
+	var nextPush time.Time
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		if time.Now().Before(nextPush) {
+			continue
+		}
+
+		if err := s.flush(); err != nil {
+			backoff *= 2
+			if backoff < lokiMinBackoff {
+				backoff = lokiMinBackoff
+			}
+			if backoff > lokiMaxBackoff {
+				backoff = lokiMaxBackoff
+			}
+			nextPush = time.Now().Add(backoff)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// flush pushes the buffered lines in batches. The lines of a failed batch are put back in front of the
+// ones written in the meantime.
+func (s *lokiSink) flush() error {
+	for {
+		s.Lock()
+		batch := s.pending
+		if len(batch) > lokiBatchSize {
+			batch = batch[:lokiBatchSize]
+		}
+		s.pending = s.pending[len(batch):]
+		s.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := s.push(batch); err != nil {
+			s.Lock()
+			s.pending = append(batch[:len(batch):len(batch)], s.pending...)
+			if len(s.pending) > lokiMaxPending {
+				s.pending = s.pending[len(s.pending)-lokiMaxPending:]
+			}
+			s.Unlock()
+			return err
+		}
+	}
+}
+
+// push sends the lines as a single stream labelled with the global tags.
+func (s *lokiSink) push(batch []lokiLine) error {
+	values := make([][2]string, len(batch))
+	for i, line := range batch {
+		values[i] = [2]string{line.timestamp, line.line}
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"streams": []map[string]interface{}{{
+			"stream": getGlobalTags(),
+			"values": values,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := s.client.Post(s.url, lokiPushContentType, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return errors.New(fmt.Sprintf("Loki push to %v failed with status %v", s.url, response.Status))
+	}
+	return nil
+}
+
+// Sync doesn't push: it's called after every log by default, which would push the lines one by one.
+// The lines are pushed once lokiBatchSize are buffered or lokiFlushInterval elapses.
+func (s *lokiSink) Sync() error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lokiPush is the payload of the Loki push API.
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+func TestLokiSink(t *testing.T) {
+	pushes := make(chan lokiPush, 10)
+	var failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var push lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			t.Errorf("invalid push: %v", err)
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != lokiPushContentType {
+			t.Errorf("got content type %v", contentType)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		pushes <- push
+	}))
+	defer server.Close()
+
+	// Every log is followed by a Sync, which mustn't push the lines one by one.
+	sink := getLokiSink(server.URL)
+	for i := 0; i < 3; i++ {
+		sink.Write([]byte(`{"msg":"batched"}` + "\n"))
+		sink.Sync()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case push := <-pushes:
+		if len(push.Streams) != 1 || len(push.Streams[0].Values) != 3 {
+			t.Fatalf("got %+v", push)
+		}
+		stream := push.Streams[0]
+		if stream.Stream["application"] == "" {
+			t.Errorf("got labels %v", stream.Stream)
+		}
+		if _, err := strconv.ParseInt(stream.Values[0][0], 10, 64); err != nil {
+			t.Errorf("got timestamp %q", stream.Values[0][0])
+		}
+		if stream.Values[0][1] != `{"msg":"batched"}` {
+			t.Errorf("got line %q", stream.Values[0][1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lines weren't pushed")
+	}
+
+	atomic.StoreInt32(&failures, 1)
+	sink.Write([]byte(`{"msg":"retried"}` + "\n"))
+
+	select {
+	case push := <-pushes:
+		if len(push.Streams) != 1 || len(push.Streams[0].Values) != 1 || push.Streams[0].Values[0][1] != `{"msg":"retried"}` {
+			t.Fatalf("got %+v", push)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the line wasn't pushed again after the failure")
+	}
+}
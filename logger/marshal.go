@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// BytesHex and BytesBase64 are the supported Config.BytesEncoding values.
+	BytesHex    = "hex"
+	BytesBase64 = "base64"
+
+	// BoolYesNo and BoolOneZero are the supported Config.BoolFormat values.
+	BoolYesNo   = "yes/no"
+	BoolOneZero = "1/0"
+)
+
+// encodeBytes renders binary data with the configured bytes encoding, hex by default.
+func encodeBytes(b []byte) string {
+	if getConfig().BytesEncoding == BytesBase64 {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+// formatBool renders a boolean with the configured bool format, as is by default.
+func formatBool(b bool) interface{} {
+	switch getConfig().BoolFormat {
+	case BoolYesNo:
+		if b {
+			return "yes"
+		}
+		return "no"
+	case BoolOneZero:
+		if b {
+			return 1
+		}
+		return 0
+	default:
+		return b
+	}
+}
+
+// maxSafeInteger is the largest integer JSON consumers using double precision numbers, such as
+// JavaScript, represent exactly.
+const maxSafeInteger = 1<<53 - 1
+
+// preciseInteger renders 64-bit integers beyond maxSafeInteger as strings with Config.Int64AsString,
+// like the protobuf JSON mapping does. Other values are returned as is.
+func preciseInteger(value interface{}) interface{} {
+	if !getConfig().Int64AsString {
+		return value
+	}
+	switch number := value.(type) {
+	case int64:
+		if number > maxSafeInteger || number < -maxSafeInteger {
+			return strconv.FormatInt(number, 10)
+		}
+	case int:
+		if int64(number) > maxSafeInteger || int64(number) < -maxSafeInteger {
+			return strconv.Itoa(number)
+		}
+	case uint64:
+		if number > maxSafeInteger {
+			return strconv.FormatUint(number, 10)
+		}
+	}
+	return value
+}
+
+// sortedMap encodes a map with string keys as an object with sorted keys, so the output is deterministic
+// whatever the encoder. Nested maps are sorted too.
+type sortedMap struct {
+	value reflect.Value
+}
+
+// asSortedMap wraps the value when it's a map with string keys.
+func asSortedMap(value interface{}) (sortedMap, bool) {
+	mapValue := reflect.ValueOf(value)
+	if mapValue.Kind() != reflect.Map || mapValue.Type().Key().Kind() != reflect.String {
+		return sortedMap{}, false
+	}
+	return sortedMap{value: mapValue}, true
+}
+
+func (m sortedMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := m.value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, key := range keys {
+		value := m.value.MapIndex(key).Interface()
+		if placeholder, ok := unsupportedValuePlaceholder(value); ok {
+			enc.AddString(key.String(), placeholder)
+		} else if marshaler, ok := value.(zapcore.ObjectMarshaler); ok {
+			if err := enc.AddObject(key.String(), marshaler); err != nil {
+				return err
+			}
+		} else if nested, ok := asSortedMap(value); ok {
+			if err := enc.AddObject(key.String(), nested); err != nil {
+				return err
+			}
+		} else if err := enc.AddReflected(key.String(), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringifyObject renders the object as compact JSON, or with fmt when it fails to marshal itself.
+func stringifyObject(obj zapcore.ObjectMarshaler) string {
+	encoder := zapcore.NewMapObjectEncoder()
+	if err := obj.MarshalLogObject(encoder); err != nil {
+		return fmt.Sprint(obj)
+	}
+	serialized, err := json.Marshal(encoder.Fields)
+	if err != nil {
+		return fmt.Sprint(obj)
+	}
+	return string(serialized)
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dottedKeyObject is an object created by nestDottedKeys, told apart from the maps logged by the
+// caller so that those are never modified.
+type dottedKeyObject map[string]interface{}
+
+// nestDottedKeys expands dotted keys into nested objects. Keys with empty segments, and keys
+// conflicting with a value already set at their path, are kept flat.
+func nestDottedKeys(values map[string]interface{}) map[string]interface{} {
+	nested := make(map[string]interface{}, len(values))
+	for _, key := range sortedKeys(values) {
+		if strings.Contains("."+key+".", "..") {
+			nested[key] = values[key]
+			continue
+		}
+
+		parts := strings.Split(key, ".")
+		parent := dottedKeyObject(nested)
+		for _, part := range parts[:len(parts)-1] {
+			if parent = dottedKeyChild(parent, part); parent == nil {
+				break
+			}
+		}
+
+		last := parts[len(parts)-1]
+		if _, exists := parent[last]; parent == nil || exists {
+			nested[key] = values[key]
+			continue
+		}
+		parent[last] = values[key]
+	}
+	return nested
+}
+
+// dottedKeyChild returns the object under key in parent, created when missing, or nil when key
+// holds another value.
+func dottedKeyChild(parent dottedKeyObject, key string) dottedKeyObject {
+	existing, found := parent[key]
+	if !found {
+		child := dottedKeyObject{}
+		parent[key] = child
+		return child
+	}
+	child, _ := existing.(dottedKeyObject)
+	return child
+}
+
+// flattenFields replaces the nested objects, maps with string keys and structs, with their leaf values
+// under dotted keys, e.g. {"http":{"method":"GET"}} becomes {"http.method":"GET"}.
+func flattenFields(values map[string]interface{}) map[string]interface{} {
+	flattened := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		flattenValue(flattened, key, value)
+	}
+	return flattened
+}
+
+func flattenValue(flattened map[string]interface{}, key string, value interface{}) {
+	if _, ok := value.(zapcore.ObjectMarshaler); !ok {
+		if object, ok := flattenableObject(value); ok && len(object) > 0 {
+			for childKey, childValue := range object {
+				flattenValue(flattened, key+"."+childKey, childValue)
+			}
+			return
+		}
+	}
+	flattened[key] = value
+}
+
+// flattenableObject provides the entries of a map with string keys, or the fields of a struct
+// as encoded in JSON.
+func flattenableObject(value interface{}) (map[string]interface{}, bool) {
+	reflected := reflect.ValueOf(value)
+	for reflected.Kind() == reflect.Ptr && !reflected.IsNil() {
+		reflected = reflected.Elem()
+	}
+
+	switch {
+	case reflected.Kind() == reflect.Map && reflected.Type().Key().Kind() == reflect.String:
+		object := make(map[string]interface{}, reflected.Len())
+		iter := reflected.MapRange()
+		for iter.Next() {
+			object[iter.Key().String()] = iter.Value().Interface()
+		}
+		return object, true
+	case reflected.Kind() == reflect.Struct:
+		var object map[string]interface{}
+		encoded, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(encoded, &object) != nil {
+			return nil, false
+		}
+		return object, object != nil
+	default:
+		return nil, false
+	}
+}
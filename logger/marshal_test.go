@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSortedMapKeys(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	WithField("counts", map[string]interface{}{
+		"delta": 4, "alpha": 1, "charlie": 3, "bravo": map[string]int{"y": 2, "x": 1},
+	}).Info("sorted")
+
+	if line := output.String(); !strings.Contains(line, `"counts":{"alpha":1,"bravo":{"x":1,"y":2},"charlie":3,"delta":4}`) {
+		t.Errorf("got %q", line)
+	}
+}
+
+func TestWithBytes(t *testing.T) {
+	data := []byte{0xde, 0xad, 0xbe, 0xef}
+	tests := []struct {
+		encoding string
+		expected string
+	}{
+		{encoding: "", expected: "deadbeef"},
+		{encoding: BytesHex, expected: "deadbeef"},
+		{encoding: BytesBase64, expected: "3q2+7w=="},
+	}
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			output := initTestLogger(t, Config{BytesEncoding: test.encoding})
+
+			WithField("id", 1).WithBytes("payload", data).Info("received")
+
+			if entry := output.lastEntry(t); entry["payload"] != test.expected {
+				t.Errorf("got %v", entry)
+			}
+		})
+	}
+}
+
+func TestNestDottedKeys(t *testing.T) {
+	output := initTestLogger(t, Config{NestDottedKeys: true})
+
+	WithFields(Fields{"http.request.method": "GET", "http.status": 200, "flat": true, "bad..key": 1}).Info("nested")
+
+	line := output.String()
+	for _, expected := range []string{`"http":{"request":{"method":"GET"},"status":200}`, `"flat":true`, `"bad..key":1`} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("%q doesn't contain %v", line, expected)
+		}
+	}
+}
+
+// testOrder has unexported fields, so it can only be logged through MarshalLogObject.
+type testOrder struct {
+	id    string
+	items int
+}
+
+func (o testOrder) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", o.id)
+	enc.AddInt("items", o.items)
+	return nil
+}
+
+func TestWithObject(t *testing.T) {
+	t.Run("production", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{}).WithObject("order", testOrder{id: "o-42", items: 3}).Info("placed")
+
+		if line := output.String(); !strings.Contains(line, `"order":{"id":"o-42","items":3}`) {
+			t.Errorf("got %q", line)
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		setEnv(t, LoggerEnvironment, development)
+		output := initTestLogger(t, Config{})
+
+		WithFields(Fields{}).WithObject("order", testOrder{id: "o-42", items: 3}).Info("placed")
+
+		if line := output.String(); !strings.Contains(line, `{"id":"o-42","items":3}`) {
+			t.Errorf("got %q", line)
+		}
+	})
+}
+
+func TestFlattenFields(t *testing.T) {
+	output := initTestLogger(t, Config{FlattenFields: true})
+	type request struct {
+		Method string `json:"method"`
+		Path   string
+	}
+
+	WithFields(Fields{
+		"http":  map[string]interface{}{"request": request{Method: "GET", Path: "/orders"}, "status": 200},
+		"order": testOrder{id: "o-42", items: 3},
+		"empty": map[string]interface{}{},
+	}).Info("flattened")
+
+	entry := output.lastEntry(t)
+	expected := map[string]interface{}{"http.request.method": "GET", "http.request.Path": "/orders", "http.status": float64(200)}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("got %v for %v in %v", entry[key], key, entry)
+		}
+	}
+	if order, _ := entry["order"].(map[string]interface{}); order["id"] != "o-42" {
+		t.Errorf("object marshalers must not be flattened: %v", entry)
+	}
+	if _, found := entry["http"]; found {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestInt64AsString(t *testing.T) {
+	output := initTestLogger(t, Config{Int64AsString: true})
+
+	WithFields(Fields{"id": int64(9007199254740993), "small": int64(42), "unsigned": uint64(1 << 63), "negative": -9007199254740993}).Info("precise")
+
+	line := output.String()
+	for _, expected := range []string{`"id":"9007199254740993"`, `"small":42`, `"unsigned":"9223372036854775808"`, `"negative":"-9007199254740993"`} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("%q doesn't contain %v", line, expected)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// omitEmptyMessageEncoding is the JSON encoding leaving out the message key of empty messages,
+// see Config.OmitEmptyMessage.
+const omitEmptyMessageEncoding = "json-omit-empty-message"
+
+// omitEmptyMessageEncoder is a JSON encoder removing the message key from the entries with an empty message.
+type omitEmptyMessageEncoder struct {
+	zapcore.Encoder
+	emptyMessage []byte // the encoded message key with an empty value
+}
+
+func newOmitEmptyMessageEncoder(encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	if encoderConfig.MessageKey == "" {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return &omitEmptyMessageEncoder{
+		Encoder:      zapcore.NewJSONEncoder(encoderConfig),
+		emptyMessage: []byte(`"` + encoderConfig.MessageKey + `":""`),
+	}
+}
+
+func (e *omitEmptyMessageEncoder) Clone() zapcore.Encoder {
+	return &omitEmptyMessageEncoder{Encoder: e.Encoder.Clone(), emptyMessage: e.emptyMessage}
+}
+
+func (e *omitEmptyMessageEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil || entry.Message != "" {
+		return line, err
+	}
+
+	// The message key comes before the fields, so its first occurrence is the entry's message.
+	encoded := line.Bytes()
+	start := bytes.Index(encoded, e.emptyMessage)
+	if start < 0 {
+		return line, nil
+	}
+	end := start + len(e.emptyMessage)
+	switch {
+	case start > 0 && encoded[start-1] == ',':
+		start--
+	case end < len(encoded) && encoded[end] == ',':
+		end++
+	}
+	omitted := append(append([]byte{}, encoded[:start]...), encoded[end:]...)
+	line.Reset()
+	line.Write(omitted)
+	return line, nil
+}
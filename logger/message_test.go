@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmptyMessage(t *testing.T) {
+	t.Run("replaced", func(t *testing.T) {
+		output := initTestLogger(t, Config{EmptyMessage: "request"})
+
+		WithField("path", "/orders").Info("")
+		Info("kept")
+
+		if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "request,kept" {
+			t.Errorf("got %v", messages)
+		}
+	})
+
+	t.Run("omitted", func(t *testing.T) {
+		output := initTestLogger(t, Config{OmitEmptyMessage: true})
+
+		WithField("path", "/orders").Info("")
+		Info("kept")
+
+		entries := output.entries(t)
+		if len(entries) != 2 {
+			t.Fatalf("got %v", entries)
+		}
+		if _, found := entries[0]["msg"]; found || entries[0]["path"] != "/orders" {
+			t.Errorf("got %v", entries[0])
+		}
+		if entries[1]["msg"] != "kept" {
+			t.Errorf("got %v", entries[1])
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	netSinkDialTimeout  = time.Second
+	netSinkWriteTimeout = time.Second
+	netSinkMaxPending   = 1000 // lines kept while disconnected, the oldest are dropped first
+	netSinkMinBackoff   = 100 * time.Millisecond
+	netSinkMaxBackoff   = 30 * time.Second
+)
+
+func init() {
+	if err := zap.RegisterSink("unix", newUnixSink); err != nil {
+		panic(err)
+	}
+	if err := zap.RegisterSink("tcp", newTCPSink); err != nil {
+		panic(err)
+	}
+	if err := zap.RegisterSink("udp", newUDPSink); err != nil {
+		panic(err)
+	}
+}
+
+// newUnixSink creates a sink for "unix:///path/to/socket" output paths.
+func newUnixSink(u *url.URL) (zap.Sink, error) {
+	if u.Path == "" {
+		return nil, errors.New(fmt.Sprintf("missing socket path in %v", u))
+	}
+	return newNetSink("unix", u.Path, false), nil
+}
+
+// newTCPSink creates a sink for "tcp://host:port" output paths, writing newline-delimited logs.
+func newTCPSink(u *url.URL) (zap.Sink, error) {
+	if u.Host == "" {
+		return nil, errors.New(fmt.Sprintf("missing host in %v", u))
+	}
+	return newNetSink("tcp", u.Host, false), nil
+}
+
+// newUDPSink creates a sink for "udp://host:port" output paths. Every log is sent as a datagram,
+// fire-and-forget: lines that fail to send are dropped and send errors are ignored.
+func newUDPSink(u *url.URL) (zap.Sink, error) {
+	if u.Host == "" {
+		return nil, errors.New(fmt.Sprintf("missing host in %v", u))
+	}
+	return newNetSink("udp", u.Host, true), nil
+}
+
+// netSink writes log lines to a socket. Write only buffers the lines, a background goroutine sends them,
+// reconnecting with exponential backoff on failure and keeping the lines while disconnected, so logging
+// never waits for the network.
+type netSink struct {
+	sync.Mutex
+	network       string
+	address       string
+	fireAndForget bool
+	pending       [][]byte
+	wake          chan struct{} // signals the sending goroutine that lines are pending
+	done          chan struct{} // closed by Close to stop the sending goroutine
+	closeOnce     sync.Once
+	conn          net.Conn // only used by the sending goroutine
+}
+
+func newNetSink(network, address string, fireAndForget bool) *netSink {
+	sink := &netSink{
+		network:       network,
+		address:       address,
+		fireAndForget: fireAndForget,
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+	go sink.send()
+	return sink
+}
+
+func (s *netSink) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	s.Lock()
+	s.pending = append(s.pending, line)
+	if len(s.pending) > netSinkMaxPending {
+		s.pending = s.pending[len(s.pending)-netSinkMaxPending:]
+	}
+	s.Unlock()
+	s.signal()
+
+	// Lines that can't be sent yet stay buffered, so the write itself never fails.
+	return len(p), nil
+}
+
+// signal wakes the sending goroutine without blocking.
+func (s *netSink) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// send runs in the background, sending the pending lines every time it's woken up
+// and retrying with exponential backoff until they're sent.
+func (s *netSink) send() {
+	defer func() {
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	}()
+
+	var backoff time.Duration
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+
+		for !s.flush() {
+			backoff *= 2
+			if backoff < netSinkMinBackoff {
+				backoff = netSinkMinBackoff
+			}
+			if backoff > netSinkMaxBackoff {
+				backoff = netSinkMaxBackoff
+			}
+			select {
+			case <-s.done:
+				return
+			case <-time.After(backoff):
+			}
+		}
+		backoff = 0
+	}
+}
+
+// flush sends the pending lines, reporting false when the socket failed. The unsent lines are kept
+// in front of the ones written in the meantime, or dropped by fire-and-forget sinks.
+func (s *netSink) flush() bool {
+	s.Lock()
+	lines := s.pending
+	s.pending = nil
+	s.Unlock()
+
+	for i, line := range lines {
+		if err := s.writeLine(line); err != nil {
+			if !s.fireAndForget {
+				s.Lock()
+				s.pending = append(lines[i:], s.pending...)
+				if len(s.pending) > netSinkMaxPending {
+					s.pending = s.pending[len(s.pending)-netSinkMaxPending:]
+				}
+				s.Unlock()
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// writeLine writes a line to the socket, dialing it when disconnected.
+func (s *netSink) writeLine(line []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, netSinkDialTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(netSinkWriteTimeout))
+	if _, err := s.conn.Write(line); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Sync only wakes the sending goroutine: it's called after every log, which must not wait for the socket.
+func (s *netSink) Sync() error {
+	s.signal()
+	return nil
+}
+
+// Close stops the sending goroutine, which closes the socket. Lines still pending are dropped.
+func (s *netSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// acceptLine accepts a connection on the listener and reads a line from it.
+func acceptLine(listener net.Listener) (string, error) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return bufio.NewReader(conn).ReadString('\n')
+}
+
+func TestUnixSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netsink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "log.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	sink, err := newUnixSink(&url.URL{Scheme: "unix", Path: socketPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sink.Write([]byte("over the socket\n"))
+
+	if line, err := acceptLine(listener); err != nil || line != "over the socket\n" {
+		t.Errorf("got %q, %v", line, err)
+	}
+}
+
+func TestTCPSinkReconnects(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	sink, err := newTCPSink(&url.URL{Scheme: "tcp", Host: address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sink.Write([]byte("before restart\n"))
+	if line, err := acceptLine(listener); err != nil || line != "before restart\n" {
+		t.Errorf("got %q, %v", line, err)
+	}
+
+	listener.Close()
+	if listener, err = net.Listen("tcp", address); err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		line, _ := acceptLine(listener)
+		received <- line
+	}()
+
+	// The first writes after the restart can still be accepted by the dead connection, so keep writing
+	// until the sink reconnects.
+	timeout := time.After(5 * time.Second)
+	for {
+		sink.Write([]byte("after restart\n"))
+		select {
+		case line := <-received:
+			if line != "after restart\n" {
+				t.Errorf("got %q", line)
+			}
+			return
+		case <-timeout:
+			t.Fatal("the sink didn't reconnect")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	loggedOnceKeys sync.Map // keys already logged by the *Once functions
+	sampledCounts  sync.Map // *uint64 count of the calls per key of the *Sampled functions
+)
+
+// firstTimeFor reports whether key is seen for the first time in the process lifetime.
+func firstTimeFor(key string) bool {
+	_, loaded := loggedOnceKeys.LoadOrStore(key, struct{}{})
+	return !loaded
+}
+
+// InfoOnce logs at INFO level only the first time it's called with key.
+func InfoOnce(key string, args ...interface{}) {
+	if !firstTimeFor(key) {
+		return
+	}
+	infoMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
+
+// WarnOnce logs at WARN level only the first time it's called with key, e.g. for deprecation notices.
+func WarnOnce(key string, args ...interface{}) {
+	if !firstTimeFor(key) {
+		return
+	}
+	warnMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
+
+// ErrorOnce logs at ERROR level only the first time it's called with key.
+func ErrorOnce(key string, args ...interface{}) {
+	if !firstTimeFor(key) {
+		return
+	}
+	errorMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
+
+// sampledFor counts a call with key and reports whether it should be logged: the first calls,
+// then every thereafter-th one. Nothing is logged after the first calls when thereafter isn't positive.
+func sampledFor(key string, first, thereafter int) bool {
+	count, _ := sampledCounts.LoadOrStore(key, new(uint64))
+	n := atomic.AddUint64(count.(*uint64), 1)
+	if n <= uint64(first) {
+		return true
+	}
+	return thereafter > 0 && (n-uint64(first))%uint64(thereafter) == 0
+}
+
+// InfoSampled logs at INFO level the first calls with key, then every thereafter-th call.
+func InfoSampled(key string, first, thereafter int, args ...interface{}) {
+	if !sampledFor(key, first, thereafter) {
+		return
+	}
+	infoMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
+
+// WarnSampled logs at WARN level the first calls with key, then every thereafter-th call.
+func WarnSampled(key string, first, thereafter int, args ...interface{}) {
+	if !sampledFor(key, first, thereafter) {
+		return
+	}
+	warnMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
+
+// ErrorSampled logs at ERROR level the first calls with key, then every thereafter-th call.
+func ErrorSampled(key string, first, thereafter int, args ...interface{}) {
+	if !sampledFor(key, first, thereafter) {
+		return
+	}
+	errorMessage(&LogMessage{Message: fmt.Sprint(args...)})
+}
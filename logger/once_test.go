@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestInfoOnce(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer loggedOnceKeys.Delete("test-info-once")
+
+	for i := 0; i < 3; i++ {
+		InfoOnce("test-info-once", "logged once")
+	}
+
+	if lines := output.lines(); len(lines) != 1 {
+		t.Errorf("got %v", lines)
+	}
+}
+
+func TestInfoSampled(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer sampledCounts.Delete("test-info-sampled")
+
+	for i := 1; i <= 10; i++ {
+		InfoSampled("test-info-sampled", 2, 3, fmt.Sprint("call ", i))
+	}
+
+	if messages := messagesOf(output.entries(t)); strings.Join(messages, ",") != "call 1,call 2,call 5,call 8" {
+		t.Errorf("got %v", messages)
+	}
+}
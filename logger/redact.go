@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+var (
+	redactedPathsMutex sync.RWMutex
+	redactedPaths      [][]string // dotted paths split into keys, see RegisterRedactedPath
+)
+
+// RegisterRedactedPath masks the value at a dotted path such as "user.ssn" in every log. The first key
+// is the field name, the following ones are JSON object keys within the field value, which is walked
+// as it's encoded in JSON logs, so struct fields are matched by their JSON name. Paths are redacted before
+// Config.FlattenFields flattens the fields, so "user.ssn" also masks the flattened "user.ssn" field.
+func RegisterRedactedPath(path string) {
+	redactedPathsMutex.Lock()
+	defer redactedPathsMutex.Unlock()
+	redactedPaths = append(redactedPaths, strings.Split(path, "."))
+}
+
+// withRedactedPaths returns a copy of the log message with the registered paths masked,
+// or the message itself when none of them is present.
+func withRedactedPaths(logMessage *LogMessage) *LogMessage {
+	redactedPathsMutex.RLock()
+	paths := redactedPaths
+	redactedPathsMutex.RUnlock()
+
+	properties := logMessage.AdditionalProperties
+	copied := false
+	for _, path := range paths {
+		value, found := properties[path[0]]
+		if !found {
+			continue
+		}
+		if !copied {
+			properties = make(map[string]interface{}, len(logMessage.AdditionalProperties))
+			for k, v := range logMessage.AdditionalProperties {
+				properties[k] = v
+			}
+			copied = true
+		}
+		if len(path) == 1 {
+			properties[path[0]] = redactedValue
+		} else {
+			properties[path[0]] = redactJSONPath(value, path[1:])
+		}
+	}
+
+	if !copied {
+		return logMessage
+	}
+	redacted := *logMessage
+	redacted.AdditionalProperties = properties
+	return &redacted
+}
+
+// redactJSONPath masks the path in the JSON representation of value. The value is returned unchanged
+// when the path isn't present, and entirely masked when it can't be represented in JSON.
+func redactJSONPath(value interface{}, path []string) interface{} {
+	var decoded interface{}
+	encoded, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(encoded, &decoded)
+	}
+	if err != nil {
+		return redactedValue
+	}
+
+	object, _ := decoded.(map[string]interface{})
+	for _, key := range path[:len(path)-1] {
+		object, _ = object[key].(map[string]interface{})
+	}
+	if _, found := object[path[len(path)-1]]; !found {
+		return value
+	}
+	object[path[len(path)-1]] = redactedValue
+	return decoded
+}
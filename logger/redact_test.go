@@ -0,0 +1,28 @@
+package logger
+
+import "testing"
+
+func TestRegisterRedactedPath(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	defer func() { redactedPaths = nil }()
+	type user struct {
+		Name string `json:"name"`
+		SSN  string `json:"ssn"`
+	}
+
+	RegisterRedactedPath("user.ssn")
+	RegisterRedactedPath("token")
+	user1 := user{Name: "alice", SSN: "123-45-6789"}
+	WithFields(Fields{"user": user1, "token": "secret", "other": "kept"}).Info("signed up")
+
+	entry := output.lastEntry(t)
+	if logged, _ := entry["user"].(map[string]interface{}); logged["ssn"] != redactedValue || logged["name"] != "alice" {
+		t.Errorf("got %v", entry)
+	}
+	if entry["token"] != redactedValue || entry["other"] != "kept" {
+		t.Errorf("got %v", entry)
+	}
+	if user1.SSN != "123-45-6789" {
+		t.Error("the logged value was modified")
+	}
+}
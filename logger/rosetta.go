@@ -1,16 +1,24 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type LogMessage struct {
 	ClientIP             string
 	CorrelationId        string
+	TraceID              string
+	SpanID               string
+	RequestID            string
+	TenantID             string
 	StartTime            time.Time
 	EndTime              time.Time
 	LatencyNanoSeconds   int64
@@ -22,7 +30,16 @@ type LogMessage struct {
 	Status               int
 	UserAgent            string
 	Message              string
+	Timestamp            time.Time // overrides the time of the log entry when set
 	AdditionalProperties map[string]interface{}
+
+	callerSkip int         // caller frames skipped for this message only, see entry.WithCallerSkip
+	zapLogger  *zap.Logger // logger of the entry that created the message, see withCore
+	indent     int         // indentation levels of development messages, see entry.Indent
+
+	lazyFields map[string]func() interface{} // computed when the message is logged, see entry.WithLazy
+
+	noStacktrace bool // logged without stack trace, see SetQuietErrors
 }
 
 func New() *LogMessage {
@@ -58,8 +75,23 @@ func DebugMessage(logMessage *LogMessage) {
 
 func (l *LogMessage) SerializeFields(skipGlobalTags bool) string {
 	var fields []string
-	if l.LoggerContext != "" {
-		fields = append(fields, fmt.Sprintf("%v=\"%v\"", loggerContext, l.LoggerContext))
+	if key := loggerContextKey(); l.LoggerContext != "" && key != "" {
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", key, l.LoggerContext))
+	}
+	if l.CorrelationId != "" {
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", correlationId, l.CorrelationId))
+	}
+	if l.TraceID != "" {
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", traceID, l.TraceID))
+	}
+	if l.SpanID != "" {
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", spanID, l.SpanID))
+	}
+	if l.RequestID != "" {
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", requestID, l.RequestID))
+	}
+	if l.TenantID != "" {
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", tenantID, l.TenantID))
 	}
 	if l.Status != 0 {
 		fields = append(fields, fmt.Sprintf("%v=%v", status, l.Status))
@@ -89,20 +121,20 @@ func (l *LogMessage) SerializeFields(skipGlobalTags bool) string {
 		fields = append(fields, fmt.Sprintf("%v=\"%v\"", endTime, l.EndTime.Format(UtcTimeFormat)))
 	}
 	if l.LatencyNanoSeconds != 0 {
-		fields = append(fields, fmt.Sprintf("%v=\"%v\"", latencyUnit, ns))
-		fields = append(fields, fmt.Sprintf("%v=%v", latency, l.LatencyNanoSeconds))
+		unit, value := renderLatency(l.LatencyNanoSeconds)
+		fields = append(fields, fmt.Sprintf("%v=\"%v\"", latencyUnit, unit))
+		fields = append(fields, fmt.Sprintf("%v=%v", latency, value))
 	}
 
-	keys := make([]string, 0, len(l.AdditionalProperties))
-	for k := range l.AdditionalProperties {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, key := range keys {
-		if reflect.TypeOf(l.AdditionalProperties[key]) == nil {
+	for _, key := range sortedKeys(l.AdditionalProperties) {
+		if placeholder, ok := unsupportedValuePlaceholder(l.AdditionalProperties[key]); ok {
+			fields = append(fields, fmt.Sprintf("%v=\"%v\"", key, placeholder))
+		} else if reflect.TypeOf(l.AdditionalProperties[key]) == nil {
 			fields = append(fields, fmt.Sprintf("%v=\"%v\"", key, nil))
 		} else if reflect.TypeOf(l.AdditionalProperties[key]).Kind() == reflect.String {
 			fields = append(fields, fmt.Sprintf("%v=\"%v\"", key, l.AdditionalProperties[key]))
+		} else if formatted, ok := formatDevFloat(l.AdditionalProperties[key]); ok {
+			fields = append(fields, fmt.Sprintf("%v=%v", key, formatted))
 		} else {
 			fields = append(fields, fmt.Sprintf("%v=%v", key, l.AdditionalProperties[key]))
 		}
@@ -113,6 +145,146 @@ func (l *LogMessage) SerializeFields(skipGlobalTags bool) string {
 			fields = append(fields, fmt.Sprintf("%v=\"%v\"", k, v))
 		}
 	}
+	if getConfig().FieldCount {
+		fields = append(fields, fmt.Sprintf("%v=%v", fieldCount, len(fields)))
+	}
 
 	return strings.Join(fields, " ")
 }
+
+// SerializeFieldsJSON serializes the fields as a compact JSON object, encoded the same way as the
+// production JSON output. Keys are sorted.
+func (l *LogMessage) SerializeFieldsJSON(skipGlobalTags bool) string {
+	fields := l.getMessageZapFields()
+	if !skipGlobalTags {
+		for k, v := range getGlobalTags() {
+			fields = append(fields, zap.String(k, v))
+		}
+	}
+	if getConfig().FieldCount {
+		fields = append(fields, zap.Int(fieldCount, len(fields)))
+	}
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	for key, value := range l.AdditionalProperties {
+		if _, found := encoder.Fields[key]; !found {
+			continue
+		}
+		if formatted, ok := formatDevFloat(value); ok {
+			// The formatted float stays a JSON number when it's one, e.g. 0.67 for "%.2f".
+			if _, err := strconv.ParseFloat(formatted, 64); err == nil && json.Valid([]byte(formatted)) {
+				encoder.Fields[key] = json.Number(formatted)
+			} else {
+				encoder.Fields[key] = formatted
+			}
+		}
+	}
+
+	serialized, err := json.Marshal(encoder.Fields)
+	if err != nil {
+		return fmt.Sprintf("{\"fieldsError\":%q}", err.Error())
+	}
+	return string(serialized)
+}
+
+// Fields returns the set built-in fields, under the same keys and formats as in the output,
+// together with the additional properties.
+func (l *LogMessage) Fields() Fields {
+	fields := Fields{}
+	if key := loggerContextKey(); l.LoggerContext != "" && key != "" {
+		fields[key] = l.LoggerContext
+	}
+	if l.CorrelationId != "" {
+		fields[correlationId] = l.CorrelationId
+	}
+	if l.TraceID != "" {
+		fields[traceID] = l.TraceID
+	}
+	if l.SpanID != "" {
+		fields[spanID] = l.SpanID
+	}
+	if l.RequestID != "" {
+		fields[requestID] = l.RequestID
+	}
+	if l.TenantID != "" {
+		fields[tenantID] = l.TenantID
+	}
+	if l.Status != 0 {
+		fields[status] = l.Status
+	}
+	if l.Method != "" {
+		fields[method] = l.Method
+	}
+	if l.Protocol != "" {
+		fields[protocol] = l.Protocol
+	}
+	if l.Path != "" {
+		fields[path] = l.Path
+	}
+	if l.Query != "" {
+		fields[query] = l.Query
+	}
+	if l.ClientIP != "" {
+		fields[clientIp] = l.ClientIP
+	}
+	if l.UserAgent != "" {
+		fields[userAgent] = l.UserAgent
+	}
+	if !l.StartTime.IsZero() {
+		fields[startTime] = l.StartTime.Format(UtcTimeFormat)
+	}
+	if !l.EndTime.IsZero() {
+		fields[endTime] = l.EndTime.Format(UtcTimeFormat)
+	}
+	if l.LatencyNanoSeconds != 0 {
+		unit, value := renderLatency(l.LatencyNanoSeconds)
+		fields[latencyUnit] = unit
+		fields[latency] = value
+	}
+	for key, val := range l.AdditionalProperties {
+		fields[key] = val
+	}
+
+	return fields
+}
+
+// formatDevFloat formats a floating point value with Config.DevFloatFormat, reporting false for other values
+// or when no format is set.
+func formatDevFloat(value interface{}) (string, bool) {
+	if !isFloat(value) || getConfig().DevFloatFormat == "" {
+		return "", false
+	}
+	return fmt.Sprintf(getConfig().DevFloatFormat, value), true
+}
+
+// isFloat reports whether the value is a floating point number.
+func isFloat(value interface{}) bool {
+	switch value.(type) {
+	case float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// unsupportedValuePlaceholder returns a safe placeholder for values that cannot be encoded,
+// such as channels, functions and unsafe pointers.
+func unsupportedValuePlaceholder(value interface{}) (string, bool) {
+	valueType := reflect.TypeOf(value)
+	if valueType == nil {
+		return "", false
+	}
+	switch valueType.Kind() {
+	case reflect.Chan:
+		return "<chan>", true
+	case reflect.Func:
+		return "<func>", true
+	case reflect.UnsafePointer:
+		return "<unsafe.Pointer>", true
+	default:
+		return "", false
+	}
+}
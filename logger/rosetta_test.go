@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFuncFieldPlaceholder(t *testing.T) {
+	t.Run("production", func(t *testing.T) {
+		output := initTestLogger(t, Config{})
+
+		WithField("callback", func() {}).Info("with a func")
+
+		if entry := output.lastEntry(t); entry["callback"] != "<func>" {
+			t.Errorf("got %v", entry)
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		setEnv(t, LoggerEnvironment, development)
+		output := initTestLogger(t, Config{})
+
+		WithField("callback", func() {}).Info("with a func")
+
+		if line := output.String(); !strings.Contains(line, `callback="<func>"`) {
+			t.Errorf("got %q", line)
+		}
+	})
+}
+
+func TestDevJSONFields(t *testing.T) {
+	setEnv(t, LoggerEnvironment, development)
+	output := initTestLogger(t, Config{DevJSONFields: true})
+
+	WithFields(Fields{"user": "alice", "attempts": 3}).Info("done")
+
+	columns := strings.Split(output.lines()[0], "\t")
+	message := columns[len(columns)-1]
+	if !strings.HasPrefix(message, "done {") {
+		t.Fatalf("got %q", message)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(message, "done ")), &fields); err != nil {
+		t.Fatalf("trailing blob of %q isn't JSON: %v", message, err)
+	}
+	if fields["user"] != "alice" || fields["attempts"] != float64(3) {
+		t.Errorf("got %v", fields)
+	}
+}
+
+func TestDevFloatFormat(t *testing.T) {
+	setEnv(t, LoggerEnvironment, development)
+	output := initTestLogger(t, Config{DevFloatFormat: "%.2f"})
+
+	WithField("ratio", 2.0/3).Info("computed")
+
+	if line := output.String(); !strings.Contains(line, "ratio=0.67") {
+		t.Errorf("got %q", line)
+	}
+}
+
+func TestDevFloatFormatJSONFields(t *testing.T) {
+	setEnv(t, LoggerEnvironment, development)
+	output := initTestLogger(t, Config{DevFloatFormat: "%.2f", DevJSONFields: true})
+
+	WithField("ratio", 2.0/3).Info("computed")
+
+	if line := output.String(); !strings.Contains(line, `{"ratio":0.67}`) {
+		t.Errorf("got %q", line)
+	}
+}
+
+func TestLogMessageFields(t *testing.T) {
+	initTestLogger(t, Config{})
+
+	logMessage := New()
+	logMessage.CorrelationId = "abc-123"
+	logMessage.Method = "GET"
+	logMessage.Status = 200
+	logMessage.AdditionalProperties["user"] = "alice"
+
+	fields := logMessage.Fields()
+	expected := Fields{correlationId: "abc-123", method: "GET", status: 200, "user": "alice"}
+	if len(fields) != len(expected) {
+		t.Fatalf("got %v", fields)
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("got %v for %v, want %v", fields[key], key, value)
+		}
+	}
+}
+
+func TestLoggerContextKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{key: "", expected: loggerContext},
+		{key: "context", expected: "context"},
+		{key: "-"},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			output := initTestLogger(t, Config{LoggerContextKey: test.key})
+
+			logMessage := New()
+			logMessage.Message = "renamed"
+			logMessage.LoggerContext = "checkout"
+			InfoMessage(logMessage)
+
+			entry := output.lastEntry(t)
+			for _, key := range []string{loggerContext, "context"} {
+				if _, found := entry[key]; found != (key == test.expected) {
+					t.Errorf("got %v", entry)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatchSignalForLevel(t *testing.T) {
+	output := initTestLogger(t, Config{})
+	stop := WatchSignalForLevel(syscall.SIGUSR1)
+	defer stop()
+	setEnv(t, LogLevel, WarnLevel)
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); output.String() == ""; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the level change wasn't logged, the level is %v", GetLevel())
+		}
+	}
+	if level := GetLevel(); level != "warn" {
+		t.Errorf("got level %v", level)
+	}
+	entry := output.lastEntry(t)
+	if msg, _ := entry["msg"].(string); !strings.HasPrefix(msg, "log level changed from info to warn") {
+		t.Errorf("got message %q", msg)
+	}
+	if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "logger/logger.go:") {
+		t.Errorf("got caller %q", caller)
+	}
+}
+
+func TestWatchSignalForLevelStop(t *testing.T) {
+	initTestLogger(t, Config{})
+	WatchSignalForLevel(syscall.SIGUSR2)()
+	setEnv(t, LogLevel, ErrorLevel)
+
+	// Without any handler left, the signal would terminate the test binary.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-signals:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the signal wasn't received")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if level := GetLevel(); level != "info" {
+		t.Errorf("the level changed to %v after stop", level)
+	}
+}
@@ -0,0 +1,131 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// slogCallerSkip skips the slog.Logger frames between the caller and the handler.
+const slogCallerSkip = 2
+
+// slogHandler is a slog.Handler logging the records through this package, with the global tags.
+type slogHandler struct {
+	fields map[string]interface{} // attributes added with WithAttrs, groups as nested maps
+	groups []string               // groups opened with WithGroup, innermost last
+}
+
+// NewSlogHandler creates a slog.Handler that logs the records like the other logs of this package,
+// with their attributes as additional properties and their groups as nested objects:
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler()))
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{fields: map[string]interface{}{}}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return GetZapLogger().Core().Enabled(slogLevelToZap(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	logMessage := New()
+	logMessage.Message = record.Message
+	logMessage.Timestamp = record.Time
+	logMessage.callerSkip = slogCallerSkip
+	logMessage.AdditionalProperties = cloneSlogFields(h.fields)
+
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	addSlogAttrs(logMessage.AdditionalProperties, h.groups, attrs)
+
+	switch slogLevelToZap(record.Level) {
+	case zapcore.DebugLevel:
+		debugMessage(logMessage)
+	case zapcore.InfoLevel:
+		infoMessage(logMessage)
+	case zapcore.WarnLevel:
+		warnMessage(logMessage)
+	default:
+		errorMessage(logMessage)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := cloneSlogFields(h.fields)
+	addSlogAttrs(fields, h.groups, attrs)
+	return &slogHandler{fields: fields, groups: h.groups}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(append([]string{}, h.groups...), name)
+	return &slogHandler{fields: h.fields, groups: groups}
+}
+
+// slogLevelToZap maps a slog level to the closest zap level, rounding down.
+func slogLevelToZap(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// addSlogAttrs adds the attributes to fields under the groups, following the slog.Handler rules:
+// empty attributes are ignored, the attributes of groups without key are inlined, and groups
+// are only created when they get an attribute.
+func addSlogAttrs(fields map[string]interface{}, groups []string, attrs []slog.Attr) {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		switch {
+		case value.Kind() != slog.KindGroup:
+			if attr.Key != "" {
+				slogGroup(fields, groups)[attr.Key] = value.Any()
+			}
+		case attr.Key == "":
+			addSlogAttrs(fields, groups, value.Group())
+		default:
+			addSlogAttrs(fields, append(groups[:len(groups):len(groups)], attr.Key), value.Group())
+		}
+	}
+}
+
+// slogGroup returns the nested map of the groups in fields, creating the missing ones.
+func slogGroup(fields map[string]interface{}, groups []string) map[string]interface{} {
+	for _, group := range groups {
+		nested, ok := fields[group].(map[string]interface{})
+		if !ok {
+			nested = map[string]interface{}{}
+			fields[group] = nested
+		}
+		fields = nested
+	}
+	return fields
+}
+
+// cloneSlogFields deep copies the group maps of the fields, so records don't share them.
+func cloneSlogFields(fields map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = cloneSlogFields(nested)
+		}
+		clone[k] = v
+	}
+	return clone
+}
@@ -0,0 +1,44 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	slog.New(NewSlogHandler()).Warn("through slog", "user", "alice")
+
+	entry := output.lastEntry(t)
+	if entry["msg"] != "through slog" || entry["level"] != "warn" || entry["user"] != "alice" {
+		t.Errorf("got %v", entry)
+	}
+	if entry["application"] == nil || entry["component"] == nil {
+		t.Errorf("missing global tags in %v", entry)
+	}
+	if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "logger/slog_test.go:") {
+		t.Errorf("got caller %v", caller)
+	}
+}
+
+func TestSlogHandlerGroups(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	requestLogger := slog.New(NewSlogHandler()).With("service", "orders").WithGroup("request").With("method", "GET")
+	requestLogger.Info("served", slog.Group("response", "status", 200), "path", "/orders", slog.Group("empty"))
+
+	line := output.String()
+	for _, expected := range []string{`"service":"orders"`, `"request":{"method":"GET","path":"/orders","response":{"status":200}}`} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("%q doesn't contain %v", line, expected)
+		}
+	}
+	if strings.Contains(line, "empty") {
+		t.Errorf("got an empty group in %q", line)
+	}
+}
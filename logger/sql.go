@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	sqlQuery = "sql"
+	sqlArgs  = "sql_args"
+)
+
+// WithQuery adds an SQL query under sql and its arguments under sql_args. The arguments are redacted
+// with Config.RedactSQLArgs, as they may contain personal data. In development, Config.InterpolateSQLArgs
+// replaces the ? and $N placeholders of the query with the arguments instead, for readability.
+func (e *entry) WithQuery(sql string, args ...interface{}) *entry {
+	if getConfig().RedactSQLArgs {
+		redacted := make([]interface{}, len(args))
+		for i := range args {
+			redacted[i] = redactedValue
+		}
+		args = redacted
+	}
+
+	if getConfig().InterpolateSQLArgs && isDevelopment() {
+		e.value[sqlQuery] = interpolateSQLArgs(sql, args)
+		return e
+	}
+	e.value[sqlQuery] = sql
+	if len(args) > 0 {
+		e.value[sqlArgs] = args
+	}
+	return e
+}
+
+// interpolateSQLArgs replaces the ? and $N placeholders with the arguments, on a best-effort basis:
+// placeholders within string literals are replaced too, and the ones without argument are kept.
+func interpolateSQLArgs(sql string, args []interface{}) string {
+	var interpolated strings.Builder
+	next := 0
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case sql[i] == '?' && next < len(args):
+			interpolated.WriteString(sqlLiteral(args[next]))
+			next++
+		case sql[i] == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			end := i + 1
+			for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+				end++
+			}
+			if n, err := strconv.Atoi(sql[i+1 : end]); err == nil && n >= 1 && n <= len(args) {
+				interpolated.WriteString(sqlLiteral(args[n-1]))
+			} else {
+				interpolated.WriteString(sql[i:end])
+			}
+			i = end - 1
+		default:
+			interpolated.WriteByte(sql[i])
+		}
+	}
+	return interpolated.String()
+}
+
+// sqlLiteral renders an argument as an SQL literal.
+func sqlLiteral(arg interface{}) string {
+	switch value := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case []byte:
+		return "'" + strings.ReplaceAll(string(value), "'", "''") + "'"
+	default:
+		return fmt.Sprint(value)
+	}
+}
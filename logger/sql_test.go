@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithQueryRedactSQLArgs(t *testing.T) {
+	output := initTestLogger(t, Config{RedactSQLArgs: true})
+
+	WithFields(Fields{}).WithQuery("SELECT * FROM users WHERE email = ?", "alice@example.com").Info("queried")
+
+	entry := output.lastEntry(t)
+	if entry[sqlQuery] != "SELECT * FROM users WHERE email = ?" {
+		t.Errorf("got %v", entry)
+	}
+	if args, _ := entry[sqlArgs].([]interface{}); len(args) != 1 || args[0] != redactedValue {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestInterpolateSQLArgs(t *testing.T) {
+	tests := []struct {
+		sql      string
+		args     []interface{}
+		expected string
+	}{
+		{sql: "SELECT * FROM users WHERE name = ? AND age > ?", args: []interface{}{"O'Brien", 30}, expected: "SELECT * FROM users WHERE name = 'O''Brien' AND age > 30"},
+		{sql: "UPDATE users SET name = $2 WHERE id = $1", args: []interface{}{7, nil}, expected: "UPDATE users SET name = NULL WHERE id = 7"},
+		{sql: "SELECT $3, ?", args: []interface{}{}, expected: "SELECT $3, ?"},
+	}
+	for _, test := range tests {
+		if interpolated := interpolateSQLArgs(test.sql, test.args); interpolated != test.expected {
+			t.Errorf("got %q, want %q", interpolated, test.expected)
+		}
+	}
+
+	t.Run("development", func(t *testing.T) {
+		setEnv(t, LoggerEnvironment, development)
+		output := initTestLogger(t, Config{InterpolateSQLArgs: true})
+
+		WithFields(Fields{}).WithQuery("DELETE FROM users WHERE id = ?", 7).Info("queried")
+
+		if line := output.String(); !strings.Contains(line, "DELETE FROM users WHERE id = 7") {
+			t.Errorf("got %q", line)
+		}
+	})
+}
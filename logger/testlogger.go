@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/mritunjaykumar/logger/logger/internal/loggerhooks"
+)
+
+func init() {
+	loggerhooks.ReplaceCore = replaceCore
+	loggerhooks.WithCore = func(core zapcore.Core) interface{} {
+		return withCore(core)
+	}
+	loggerhooks.TeeCore = teeCore
+	loggerhooks.ParseLevel = parseLogLevel
+}
+
+// replaceCore logs through the core instead of the configured outputs, wrapped like the cores built by Init,
+// until restore puts back the previous logger. It's the loggertest.NewTestLogger hook.
+func replaceCore(core zapcore.Core) (restore func()) {
+	core = wrapCore(*getConfig(), isDevelopment(), core)
+	restoreLogger := swapZapLogger(func(previous *zap.Logger) *zap.Logger {
+		return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(callerSkipOffset+getCallerSkip()))
+	})
+	previousMainCore := setVerboseMainCore(core)
+	return func() {
+		restoreLogger()
+		setVerboseMainCore(previousMainCore)
+	}
+}
+
+// teeCore also writes every log to the core, next to the configured outputs, until restore puts back the
+// previous logger. It's the loggertest.ExpectNoLogsAbove hook.
+func teeCore(core zapcore.Core) (restore func()) {
+	return swapZapLogger(func(previous *zap.Logger) *zap.Logger {
+		return previous.WithOptions(zap.WrapCore(func(previousCore zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(previousCore, core)
+		}))
+	})
+}
+
+// swapZapLogger installs the logger built from the current one, returning the function restoring it.
+// A logger rebuilt in the meantime, e.g. by Init, is kept: the outputs of the previous one are closed.
+func swapZapLogger(build func(previous *zap.Logger) *zap.Logger) (restore func()) {
+	previous := GetZapLogger()
+	zapLoggerSwapMutex.Lock()
+	defer zapLoggerSwapMutex.Unlock()
+
+	installed := build(previous)
+	zapLogger.Store(installed)
+	return func() {
+		zapLoggerSwapMutex.Lock()
+		defer zapLoggerSwapMutex.Unlock()
+		if zapLogger.Load().(*zap.Logger) == installed {
+			zapLogger.Store(previous)
+		}
+	}
+}
+
+// withCore creates an entry that logs through the core, wrapped like the cores built by Init, instead of
+// the package logger, e.g. to attribute the logs of a test to it. It's the loggertest.NewTBLogger hook.
+func withCore(core zapcore.Core) *entry {
+	return &entry{
+		value: make(Fields),
+		zapLogger: zap.New(wrapCore(*getConfig(), isDevelopment(), core),
+			zap.AddCaller(), zap.AddCallerSkip(callerSkipOffset+getCallerSkip())),
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+const operation = "operation"
+
+// TimeOperation starts timing an operation and returns the function that stops it, usually deferred.
+// The operation is logged at WARN level with the latency fields only when it took longer than threshold:
+//
+//	defer logger.TimeOperation("load users", 100*time.Millisecond)()
+func TimeOperation(name string, threshold time.Duration) func() {
+	start := time.Now()
+	return func() {
+		end := time.Now()
+		elapsed := end.Sub(start)
+		if elapsed <= threshold {
+			return
+		}
+
+		logMessage := New()
+		logMessage.Message = fmt.Sprintf("slow operation %v took %v (threshold %v)", name, elapsed, threshold)
+		logMessage.StartTime = start.UTC()
+		logMessage.EndTime = end.UTC()
+		logMessage.LatencyNanoSeconds = elapsed.Nanoseconds()
+		logMessage.AdditionalProperties[operation] = name
+		warnMessage(logMessage)
+	}
+}
+
+// Mark starts a stopwatch and returns the function that logs a message at INFO level with the latency
+// fields of the time elapsed since the mark. It can be called several times, e.g. for each step:
+//
+//	lap := logger.Mark()
+//	parse()
+//	lap("parsed")
+func Mark() func(msg string) {
+	start := time.Now()
+	return func(msg string) {
+		end := time.Now()
+		logMessage := New()
+		logMessage.Message = msg
+		logMessage.StartTime = start.UTC()
+		logMessage.EndTime = end.UTC()
+		logMessage.LatencyNanoSeconds = end.Sub(start).Nanoseconds()
+		infoMessage(logMessage)
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeOperation(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	TimeOperation("fast", time.Hour)()
+	stop := TimeOperation("slow", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	stop()
+
+	entries := output.entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %v", entries)
+	}
+	if entry := entries[0]; entry[operation] != "slow" || entry["level"] != "warn" || entry[latency].(float64) < float64(5*time.Millisecond) {
+		t.Errorf("got %v", entry)
+	}
+}
+
+func TestMark(t *testing.T) {
+	output := initTestLogger(t, Config{})
+
+	lap := Mark()
+	time.Sleep(2 * time.Millisecond)
+	lap("parsed")
+	time.Sleep(2 * time.Millisecond)
+	lap("validated")
+
+	entries := output.entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %v", entries)
+	}
+	parsed, validated := entries[0][latency].(float64), entries[1][latency].(float64)
+	if parsed < float64(2*time.Millisecond) || validated < parsed+float64(2*time.Millisecond) {
+		t.Errorf("got latencies %v and %v", parsed, validated)
+	}
+	if entries[0][startTime] != entries[1][startTime] {
+		t.Errorf("got start times %v and %v", entries[0][startTime], entries[1][startTime])
+	}
+}
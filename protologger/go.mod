@@ -0,0 +1,10 @@
+module github.com/mritunjaykumar/logger/protologger
+
+go 1.14
+
+require (
+	github.com/mritunjaykumar/logger v0.0.0-00010101000000-000000000000
+	google.golang.org/protobuf v1.25.0
+)
+
+replace github.com/mritunjaykumar/logger => ../
@@ -0,0 +1,34 @@
+// Package protologger logs protobuf messages compactly with the logger package. It lives in its own
+// module so the logger package doesn't depend on protobuf. It replaces the WithProto method of the
+// logger entries, which was only built with the "protobuf" build tag:
+//
+//	logger.WithField("order", protologger.Value(order)).Info("received")
+package protologger
+
+import (
+	"encoding/json"
+
+	"github.com/mritunjaykumar/logger/logger"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Value renders a protobuf message as a field value: compact protojson nested in JSON logs, and the
+// concise text format in development logs.
+func Value(msg proto.Message) interface{} {
+	if logger.IsDevelopment() {
+		return prototext.MarshalOptions{}.Format(msg)
+	}
+
+	// Decoded back to plain values, so the message is nested in the JSON output like any other object.
+	var decoded interface{}
+	encoded, err := protojson.MarshalOptions{}.Marshal(msg)
+	if err == nil {
+		err = json.Unmarshal(encoded, &decoded)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return decoded
+}
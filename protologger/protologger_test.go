@@ -0,0 +1,48 @@
+package protologger
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mritunjaykumar/logger/logger"
+	"github.com/mritunjaykumar/logger/logger/loggertest"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValue(t *testing.T) {
+	order := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"id": {Kind: &structpb.Value_StringValue{StringValue: "o-42"}},
+	}}
+
+	t.Run("production", func(t *testing.T) {
+		logs := loggertest.NewTestLogger()
+		defer logs.Close()
+
+		logger.WithField("order", Value(order)).Info("received")
+
+		entries := logs.Entries()
+		if len(entries) != 1 {
+			t.Fatalf("got %v", entries)
+		}
+		if expected := map[string]interface{}{"id": "o-42"}; !reflect.DeepEqual(entries[0]["order"], expected) {
+			t.Errorf("got %v", entries[0]["order"])
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		if err := os.Setenv(logger.LoggerEnvironment, "DEVELOPMENT"); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			os.Unsetenv(logger.LoggerEnvironment)
+			logger.MustInit(logger.Config{})
+		}()
+		logger.MustInit(logger.Config{})
+
+		if value, _ := Value(order).(string); !strings.Contains(value, `"o-42"`) {
+			t.Errorf("got %q", value)
+		}
+	})
+}